@@ -8,6 +8,12 @@ export class GameState {
         if (this.state.Board) {
             this.state.Board = this.state.Board.map(row => this.decodeBase64ToUint8Array(row));
         }
+        if (this.state.Owners) {
+            this.state.Owners = this.state.Owners.map(row => this.decodeBase64ToUint8Array(row));
+        }
+        if (this.state.Density) {
+            this.state.Density = this.state.Density.map(row => this.decodeBase64ToUint8Array(row));
+        }
         console.log("[GameState] Updated state:", this.state);
     }
 
@@ -27,10 +33,12 @@ export class GameState {
 
     getLiveCells() {
         if (!this.state || !this.state.Board) return 0;
+        // Border cells are never alive, so every row can be counted whole,
+        // whether the state holds the board or a viewport of it.
         let liveCells = 0;
-        for (let y = 1; y < this.state.Height - 1; y++) {
-            for (let x = 1; x < this.state.Width - 1; x++) {
-                if (this.state.Board[y][x] >= 100) liveCells++;
+        for (const row of this.state.Board) {
+            for (const cell of row) {
+                if (cell >= 100) liveCells++;
             }
         }
         return liveCells;
@@ -0,0 +1,16 @@
+// boardLayout fits the playable area of a board, inside its one-cell dead
+// border, into a canvas with square cells, centred and letterboxed, so a
+// board keeps its shape in any window. Cell (x, y) is drawn at
+// (offsetX + (x - 1) * cellSize, offsetY + (y - 1) * cellSize).
+export function boardLayout(canvasWidth, canvasHeight, boardWidth, boardHeight) {
+    const cols = Math.max(boardWidth - 2, 1);
+    const rows = Math.max(boardHeight - 2, 1);
+    const cellSize = Math.min(canvasWidth / cols, canvasHeight / rows);
+    return {
+        cellSize: cellSize,
+        offsetX: (canvasWidth - cols * cellSize) / 2,
+        offsetY: (canvasHeight - rows * cellSize) / 2,
+        width: cols * cellSize,
+        height: rows * cellSize
+    };
+}
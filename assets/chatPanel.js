@@ -0,0 +1,52 @@
+// maxChatLines bounds the chat log kept in the page, like the server's
+// scrollback.
+const maxChatLines = 50;
+
+export class ChatPanel {
+    constructor(webSocketClient, config) {
+        this.webSocketClient = webSocketClient;
+        this.config = config;
+        this.element = document.getElementById("chat");
+        this.logElement = document.createElement("ul");
+        this.form = document.createElement("form");
+        this.input = document.createElement("input");
+        this.input.maxLength = 500;
+        this.input.placeholder = "Chat...";
+        this.form.append(this.input);
+        this.element.append(this.logElement, this.form);
+
+        this.form.addEventListener("submit", (e) => {
+            e.preventDefault();
+            const text = this.input.value.trim();
+            if (!text) return;
+            this.webSocketClient.send({type: "chat", text: text, gameID: this.config.getGameID()});
+            this.input.value = "";
+        });
+    }
+
+    setHistory(messages) {
+        this.logElement.replaceChildren();
+        messages.forEach(message => this.addMessage(message));
+    }
+
+    addMessage(message) {
+        this.append(`${message.name}: ${message.text}`, message.time);
+    }
+
+    // addNotice shows an announcement from the server's operators.
+    addNotice(notice) {
+        this.append(`Server notice: ${notice.text}`, notice.time).className = "notice";
+    }
+
+    append(text, timestamp) {
+        const item = document.createElement("li");
+        const time = new Date(timestamp).toLocaleTimeString([], {hour: "2-digit", minute: "2-digit"});
+        item.textContent = `[${time}] ${text}`;
+        this.logElement.append(item);
+        while (this.logElement.children.length > maxChatLines) {
+            this.logElement.firstChild.remove();
+        }
+        this.logElement.scrollTop = this.logElement.scrollHeight;
+        return item;
+    }
+}
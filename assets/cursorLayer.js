@@ -0,0 +1,81 @@
+const cursorColors = ["#ffffff", "#e74c3c", "#2ecc71", "#3498db", "#f1c40f"];
+
+// Draws the other players' cursors and selections on a transparent canvas
+// over the board, so they can move without redrawing the game.
+export class CursorLayer {
+    constructor(viewport) {
+        this.viewport = viewport;
+        this.canvas = document.getElementById("cursors");
+        this.ctx = this.canvas.getContext("2d");
+        this.cursors = new Map();
+        this.boardWidth = 1;
+        this.boardHeight = 1;
+        this.resize();
+        window.addEventListener("resize", () => this.resize());
+    }
+
+    resize() {
+        this.canvas.width = window.innerWidth;
+        this.canvas.height = window.innerHeight;
+        this.draw();
+    }
+
+    setBoardSize(width, height) {
+        if (width === this.boardWidth && height === this.boardHeight) return;
+        this.boardWidth = width;
+        this.boardHeight = height;
+        this.draw();
+    }
+
+    // update stores a "cursor" or "selection" message from another player.
+    update(data) {
+        const entry = this.cursors.get(data.playerID) || {};
+        entry.name = data.name;
+        entry.team = data.team || 0;
+        if (data.type === "cursor") {
+            entry.cursor = {x: data.x, y: data.y};
+        } else if (data.width > 0 && data.height > 0) {
+            entry.selection = {x: data.x, y: data.y, width: data.width, height: data.height};
+        } else {
+            delete entry.selection;
+        }
+        this.cursors.set(data.playerID, entry);
+        this.draw();
+    }
+
+    // retain forgets the cursors of players who have left the game.
+    retain(players) {
+        const ids = new Set(players.map(player => player.id));
+        for (const id of this.cursors.keys()) {
+            if (!ids.has(id)) this.cursors.delete(id);
+        }
+        this.draw();
+    }
+
+    draw() {
+        const ctx = this.ctx;
+        const {cellSize, offsetX, offsetY} = this.viewport.layout(this.canvas.width, this.canvas.height, this.boardWidth, this.boardHeight);
+        ctx.clearRect(0, 0, this.canvas.width, this.canvas.height);
+        ctx.font = "12px Arial";
+        ctx.lineWidth = 2;
+        for (const entry of this.cursors.values()) {
+            const color = cursorColors[entry.team] || cursorColors[0];
+            ctx.strokeStyle = color;
+            ctx.fillStyle = color;
+            if (entry.selection) {
+                const s = entry.selection;
+                ctx.setLineDash([6, 4]);
+                ctx.strokeRect(offsetX + (s.x - 1) * cellSize, offsetY + (s.y - 1) * cellSize, s.width * cellSize, s.height * cellSize);
+                ctx.setLineDash([]);
+            }
+            if (entry.cursor) {
+                const px = offsetX + (entry.cursor.x - 0.5) * cellSize;
+                const py = offsetY + (entry.cursor.y - 0.5) * cellSize;
+                ctx.beginPath();
+                ctx.arc(px, py, 4, 0, 2 * Math.PI);
+                ctx.fill();
+                ctx.fillText(entry.name, px + 8, py - 8);
+            }
+        }
+    }
+}
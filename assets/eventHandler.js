@@ -1,9 +1,19 @@
+// cursorInterval matches the server's rate limit on cursor messages.
+const cursorInterval = 50;
+
+// panKeys maps arrow keys to the direction they pan the view, by a quarter
+// of the window.
+const panKeys = {ArrowLeft: [-1, 0], ArrowRight: [1, 0], ArrowUp: [0, -1], ArrowDown: [0, 1]};
+
 export class EventHandler {
-    constructor(canvasManager, webSocketClient, config) {
+    constructor(canvasManager, webSocketClient, config, viewport) {
         this.canvasManager = canvasManager;
         this.webSocketClient = webSocketClient;
         this.config = config;
+        this.viewport = viewport;
         this.inputBuffer = "";
+        this.lastCursorSent = 0;
+        this.selectionStart = null;
         this.setupEvents();
     }
 
@@ -11,11 +21,8 @@ export class EventHandler {
         const canvas = this.canvasManager.canvas;
 
         canvas.addEventListener("click", (e) => {
-            const rect = canvas.getBoundingClientRect();
-            const cellWidth = rect.width / this.config.getBoardWidth();
-            const cellHeight = rect.height / this.config.getBoardHeight();
-            const x = Math.min(this.config.getBoardWidth() - 2, Math.max(1, Math.floor((e.clientX - rect.left) / cellWidth)));
-            const y = Math.min(this.config.getBoardHeight() - 2, Math.max(1, Math.floor((e.clientY - rect.top) / cellHeight)));
+            if (e.shiftKey) return;
+            const {x, y} = this.cellAt(e);
             this.sendMessage({
                 type: "birth",
                 x: x,
@@ -24,8 +31,43 @@ export class EventHandler {
             });
         });
 
+        // Cursor moves are shared with the other players, at most every
+        // cursorInterval ms. Shift-dragging shares a selection rectangle.
+        canvas.addEventListener("mousedown", (e) => {
+            if (e.shiftKey) this.selectionStart = this.cellAt(e);
+        });
+        canvas.addEventListener("mousemove", (e) => {
+            const now = Date.now();
+            if (now - this.lastCursorSent < cursorInterval) return;
+            this.lastCursorSent = now;
+            const cell = this.cellAt(e);
+            this.sendQuiet({type: "cursor", x: cell.x, y: cell.y, gameID: this.config.getGameID()});
+            if (this.selectionStart && e.buttons === 1) this.sendSelection(this.selectionStart, cell);
+        });
+        canvas.addEventListener("mouseup", (e) => {
+            if (!this.selectionStart) return;
+            this.sendSelection(this.selectionStart, this.cellAt(e));
+            this.selectionStart = null;
+        });
+
+        // The mouse wheel zooms around the pointer.
+        canvas.addEventListener("wheel", (e) => {
+            e.preventDefault();
+            const rect = canvas.getBoundingClientRect();
+            this.viewport.zoomAt(-Math.sign(e.deltaY), e.clientX - rect.left, e.clientY - rect.top);
+        }, {passive: false});
+
         document.addEventListener("keydown", (e) => {
             if (e.key === "Shift" || e.key === "Control" || e.key === "Alt") return;
+            if (e.target instanceof HTMLInputElement) return;
+            if (e.key === "Escape") {
+                this.sendQuiet({type: "selection", x: 1, y: 1, width: 0, height: 0, gameID: this.config.getGameID()});
+                return;
+            }
+            if (this.handleViewKey(e.key)) {
+                e.preventDefault();
+                return;
+            }
 
             this.inputBuffer += e.key.toLowerCase();
             console.log("[EventHandler] Input buffer updated:", this.inputBuffer);
@@ -39,6 +81,9 @@ export class EventHandler {
                 { input: "random", type: "randomBirth", percentage: 50 },
                 { input: "stop", type: "stop" },
                 { input: "resume", type: "resume" },
+                { input: "start", type: "startRound" },
+                { input: "ready", type: "ready" },
+                { input: "fit", type: "resize", ...this.config.getWindowBoardSize(), anchor: "center" },
                 { input: "slide", type: "pattern", pattern: "glider" },
                 { input: "blink", type: "pattern", pattern: "blinker" },
                 { input: "toad", type: "pattern", pattern: "toad" },
@@ -67,7 +112,59 @@ export class EventHandler {
         });
     }
 
+    // handleViewKey pans with the arrow keys, zooms with + and - and goes
+    // back to showing the whole board with Home. It reports whether key was
+    // one of them.
+    handleViewKey(key) {
+        const canvas = this.canvasManager.canvas;
+        if (panKeys[key]) {
+            const [dx, dy] = panKeys[key];
+            this.viewport.pan(dx * canvas.width / 4, dy * canvas.height / 4);
+        } else if (key === "+" || key === "=") {
+            this.viewport.zoomAt(1, canvas.width / 2, canvas.height / 2);
+        } else if (key === "-") {
+            this.viewport.zoomAt(-1, canvas.width / 2, canvas.height / 2);
+        } else if (key === "Home") {
+            this.viewport.reset();
+        } else {
+            return false;
+        }
+        return true;
+    }
+
+    // cellAt maps a mouse event to board coordinates inside the dead border,
+    // using the size of the board the server last sent.
+    cellAt(e) {
+        const rect = this.canvasManager.canvas.getBoundingClientRect();
+        const width = this.config.getBoardWidth();
+        const height = this.config.getBoardHeight();
+        const {cellSize, offsetX, offsetY} = this.viewport.layout(rect.width, rect.height, width, height);
+        return {
+            x: Math.min(width - 2, Math.max(1, 1 + Math.floor((e.clientX - rect.left - offsetX) / cellSize))),
+            y: Math.min(height - 2, Math.max(1, 1 + Math.floor((e.clientY - rect.top - offsetY) / cellSize)))
+        };
+    }
+
+    sendSelection(start, end) {
+        this.sendQuiet({
+            type: "selection",
+            x: Math.min(start.x, end.x),
+            y: Math.min(start.y, end.y),
+            width: Math.abs(end.x - start.x) + 1,
+            height: Math.abs(end.y - start.y) + 1,
+            gameID: this.config.getGameID()
+        });
+    }
+
     sendMessage(message) {
         this.webSocketClient.send(message);
     }
+
+    // sendQuiet sends frequent messages such as cursor moves without logging
+    // each one.
+    sendQuiet(message) {
+        if (this.webSocketClient.ws.readyState === WebSocket.OPEN) {
+            this.webSocketClient.ws.send(JSON.stringify(message));
+        }
+    }
 }
\ No newline at end of file
@@ -1,8 +1,9 @@
 export class GameConfig {
     constructor() {
         this.cellSize = 5; // Adjustable cell density
-        this.boardWidth = Math.floor(window.innerWidth / this.cellSize);
-        this.boardHeight = Math.floor(window.innerHeight / this.cellSize);
+        // The size a new game is created with; joining an existing game or a
+        // resize replaces it with the server's size, see setBoardSize.
+        ({width: this.boardWidth, height: this.boardHeight} = this.getWindowBoardSize());
         this.step = 50;
         this.minInterval = 50;
         this.maxInterval = 2000;
@@ -26,10 +27,68 @@ export class GameConfig {
         return this.boardHeight;
     }
 
+    setBoardSize(width, height) {
+        this.boardWidth = width;
+        this.boardHeight = height;
+    }
+
+    // Board size, dead border included, that fills the window at cellSize.
+    getWindowBoardSize() {
+        return {
+            width: Math.floor(window.innerWidth / this.cellSize) + 2,
+            height: Math.floor(window.innerHeight / this.cellSize) + 2
+        };
+    }
+
     getGameID() {
         return this.gameID;
     }
 
+    // WebSocket endpoint on the server that served the page, over TLS if
+    // the page was, passing on a shared secret from the URL (?auth=...).
+    getWebSocketURL() {
+        const protocol = window.location.protocol === "https:" ? "wss:" : "ws:";
+        const url = new URL(`${protocol}//${window.location.host}/ws`);
+        const auth = new URLSearchParams(window.location.search).get("auth");
+        if (auth) url.searchParams.set("auth", auth);
+        return url.toString();
+    }
+
+
+    // Initial fill and game mode for a new game, taken from the URL query,
+    // e.g. ?fill=empty, ?fill=c4&density=30 or ?mode=quadlife
+    getInitialFill() {
+        const params = new URLSearchParams(window.location.search);
+        const fill = {};
+        if (params.has("fill")) fill.fill = params.get("fill");
+        if (params.has("density")) fill.density = Number(params.get("density"));
+        if (params.has("pattern")) fill.pattern = params.get("pattern");
+        if (params.has("rle")) fill.rle = params.get("rle");
+        if (params.has("mode")) fill.mode = params.get("mode");
+        for (const key of ["budget", "generations", "setupSeconds"]) {
+            if (params.has(key)) fill[key] = Number(params.get(key));
+        }
+        return fill;
+    }
+
+    // Token proving edit rights: an invite token from the URL (?invite=...)
+    // or the owner token remembered from creating this game.
+    getToken() {
+        const params = new URLSearchParams(window.location.search);
+        return params.get("invite") || localStorage.getItem(`owner:${this.gameID}`) || "";
+    }
+
+    // Player name from the URL (?name=...) or the last one used.
+    getPlayerName() {
+        const params = new URLSearchParams(window.location.search);
+        const name = params.get("name") || localStorage.getItem("playerName") || "";
+        if (name) localStorage.setItem("playerName", name);
+        return name;
+    }
+
+    saveOwnerToken(token) {
+        localStorage.setItem(`owner:${this.gameID}`, token);
+    }
 
     getStep() {
         return this.step;
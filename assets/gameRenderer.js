@@ -1,6 +1,14 @@
+// letterboxColor fills the parts of the window the board does not cover.
+const letterboxColor = "#000";
+
+// minDensityAlpha keeps blocks with a single live cell visible when zoomed
+// out.
+const minDensityAlpha = 0.25;
+
 export class GameRenderer {
-    constructor(canvasManager) {
+    constructor(canvasManager, viewport) {
         this.canvasManager = canvasManager;
+        this.viewport = viewport;
     }
 
     render(gameState) {
@@ -9,27 +17,54 @@ export class GameRenderer {
         const height = this.canvasManager.getHeight();
 
         console.log("[GameRenderer] Rendering - BackgroundColor:", gameState.BackgroundColor, "Color:", gameState.Color);
-        ctx.fillStyle = gameState.BackgroundColor;
+        const layout = this.viewport.layout(width, height, gameState.Width, gameState.Height);
+        ctx.fillStyle = letterboxColor;
         ctx.fillRect(0, 0, width, height);
+        ctx.fillStyle = gameState.BackgroundColor;
+        ctx.fillRect(layout.offsetX, layout.offsetY, layout.width, layout.height);
         ctx.fillStyle = gameState.Color;
 
-        const cellWidth = width / gameState.Width;
-        const cellHeight = height / gameState.Height;
+        const cellSize = layout.cellSize;
         console.log("[GameRenderer] Board dimensions:", gameState.Width, "x", gameState.Height);
-        console.log("[GameRenderer] Cell dimensions:", cellWidth, "x", cellHeight);
+        console.log("[GameRenderer] Cell size:", cellSize);
 
-        let liveCells = 0;
-        for (let y = 1; y < gameState.Height - 1; y++) {
-            for (let x = 1; x < gameState.Width - 1; x++) {
-                if (gameState.Board[y][x] >= 100) {
-                    liveCells++;
-                    ctx.fillRect((x - 1) * cellWidth, (y - 1) * cellHeight, cellWidth, cellHeight);
-                }
-            }
+        // Without a View the state holds the whole board; with one, Board
+        // and Owners start at the View's top-left cell.
+        const view = gameState.View || {X: 0, Y: 0, Zoom: 1};
+        const colorOf = (row, col) => {
+            if (!gameState.Owners) return gameState.Color;
+            const owner = gameState.Owners[row][col];
+            return owner > 0 ? gameState.TeamColors[owner - 1] : gameState.Color;
+        };
+
+        if (gameState.Density) {
+            const blockSize = view.Zoom * cellSize;
+            gameState.Density.forEach((densities, row) => {
+                densities.forEach((density, col) => {
+                    if (density === 0) return;
+                    ctx.globalAlpha = minDensityAlpha + (1 - minDensityAlpha) * density / 255;
+                    ctx.fillStyle = colorOf(row, col);
+                    ctx.fillRect(layout.offsetX + (view.X + col * view.Zoom - 1) * cellSize,
+                        layout.offsetY + (view.Y + row * view.Zoom - 1) * cellSize, blockSize, blockSize);
+                });
+            });
+            ctx.globalAlpha = 1;
+            return;
         }
+
+        let liveCells = 0;
+        (gameState.Board || []).forEach((cells, row) => {
+            cells.forEach((cell, col) => {
+                if (cell < 100) return;
+                liveCells++;
+                ctx.fillStyle = colorOf(row, col);
+                ctx.fillRect(layout.offsetX + (view.X + col - 1) * cellSize,
+                    layout.offsetY + (view.Y + row - 1) * cellSize, cellSize, cellSize);
+            });
+        });
         console.log("[GameRenderer] Number of live cells rendered:", liveCells);
 
-        if (gameState.Stopped && liveCells === 0) {
+        if (gameState.Stopped && liveCells === 0 && !gameState.View) {
             console.log("[GameRenderer] Game stopped: No live cells remaining");
             ctx.fillStyle = "white";
             ctx.font = `${Math.min(width, height) / 20}px Arial`;
@@ -37,4 +72,4 @@ export class GameRenderer {
             ctx.fillText("Game Over: No Live Cells", width / 2, height / 2);
         }
     }
-}
\ No newline at end of file
+}
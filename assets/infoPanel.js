@@ -0,0 +1,65 @@
+export class InfoPanel {
+    constructor() {
+        this.element = document.getElementById("info");
+        this.roleElement = document.createElement("div");
+        this.playersElement = document.createElement("ul");
+        this.actionElement = document.createElement("div");
+        this.scoresElement = document.createElement("ol");
+        this.roundElement = document.createElement("div");
+        this.resultElement = document.createElement("div");
+        this.element.append(this.roleElement, this.playersElement, this.actionElement, this.scoresElement, this.roundElement, this.resultElement);
+    }
+
+    setRole(role, inviteLink) {
+        this.roleElement.textContent = `Role: ${role}`;
+        if (inviteLink) {
+            const link = document.createElement("a");
+            link.href = inviteLink;
+            link.textContent = "invite link";
+            this.roleElement.append(" · ", link);
+        }
+        console.log("[InfoPanel] Role set to:", role);
+    }
+
+    setPlayers(players, selfID) {
+        this.playersElement.replaceChildren(...players.map(player => {
+            const item = document.createElement("li");
+            item.textContent = `${player.name} (${player.role}${player.team ? `, team ${player.team}` : ""})${player.id === selfID ? " - you" : ""}`;
+            return item;
+        }));
+        console.log("[InfoPanel] Players updated:", players.length);
+    }
+
+    setScores(scores) {
+        this.scoresElement.replaceChildren(...scores.map(score => {
+            const item = document.createElement("li");
+            item.style.color = score.color;
+            item.textContent = `${score.name}: ${score.cells}`;
+            return item;
+        }));
+    }
+
+    setRound(round) {
+        let detail = "";
+        if (round.phase === "setup") {
+            detail = ` · cells left ${round.remaining.join(" / ")} · ${round.secondsLeft}s · ready ${round.ready.map(r => r ? "yes" : "no").join(" / ")}`;
+        } else if (round.phase === "running") {
+            detail = ` · generation ${round.generation} of ${round.generations}`;
+        }
+        this.roundElement.textContent = `Round ${round.round}: ${round.phase}${detail}`;
+    }
+
+    setResult(result) {
+        const winner = result.winner ? `${result.winnerName || `team ${result.winner}`} wins` : "Draw";
+        this.resultElement.textContent = `Round ${result.round}: ${winner} (${result.scores.join(" - ")})`;
+        console.log("[InfoPanel] Round result:", result);
+    }
+
+    setLastAction(action) {
+        let detail = action.pattern || action.color || "";
+        if (action.action === "birth") detail = `(${action.x}, ${action.y})`;
+        if (action.action === "randomBirth") detail = `${action.percentage}%`;
+        if (action.action === "resize") detail = `to ${action.width}x${action.height} (${action.anchor || "center"})`;
+        this.actionElement.textContent = `${action.name}: ${action.action} ${detail}`.trim();
+    }
+}
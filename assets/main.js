@@ -4,23 +4,87 @@ import {GameState} from './gameState.js';
 import {GameRenderer} from './gameRenderer.js';
 import {EventHandler} from './eventHandler.js';
 import {GameConfig} from './gameConfig.js';
+import {InfoPanel} from './infoPanel.js';
+import {CursorLayer} from './cursorLayer.js';
+import {ChatPanel} from './chatPanel.js';
+import {Viewport} from './viewport.js';
 
 class GameClient {
     constructor() {
         this.config = new GameConfig();
         this.canvasManager = new CanvasManager();
-        this.webSocketClient = new WebSocketClient("ws://localhost:8080/ws");
+        this.webSocketClient = new WebSocketClient(this.config.getWebSocketURL());
         this.gameState = new GameState();
-        this.gameRenderer = new GameRenderer(this.canvasManager);
-        this.eventHandler = new EventHandler(this.canvasManager, this.webSocketClient, this.config);
+        this.viewport = new Viewport(this.canvasManager, this.webSocketClient, this.config);
+        this.gameRenderer = new GameRenderer(this.canvasManager, this.viewport);
+        this.eventHandler = new EventHandler(this.canvasManager, this.webSocketClient, this.config, this.viewport);
+        this.infoPanel = new InfoPanel();
+        this.cursorLayer = new CursorLayer(this.viewport);
+        this.chatPanel = new ChatPanel(this.webSocketClient, this.config);
     }
 
     init() {
 
         // Send init message immediately after WebSocket opens
         this.webSocketClient.onMessage((data) => {
+            if (data.type === "error") {
+                console.error("[GameClient] Server error:", data.message);
+                return;
+            }
+            if (data.type === "role") {
+                this.handleRole(data);
+                return;
+            }
+            if (data.type === "presence") {
+                this.infoPanel.setPlayers(data.players, this.playerID);
+                this.cursorLayer.retain(data.players);
+                return;
+            }
+            if (data.type === "chat") {
+                this.chatPanel.addMessage(data);
+                return;
+            }
+            if (data.type === "notice") {
+                this.chatPanel.addNotice(data);
+                return;
+            }
+            if (data.type === "chatHistory") {
+                this.chatPanel.setHistory(data.messages);
+                return;
+            }
+            if (data.type === "cursor" || data.type === "selection") {
+                this.cursorLayer.update(data);
+                return;
+            }
+            if (data.type === "action") {
+                this.infoPanel.setLastAction(data);
+                return;
+            }
+            if (data.type === "scores") {
+                this.infoPanel.setScores(data.scores);
+                return;
+            }
+            if (data.type === "round") {
+                this.infoPanel.setRound(data);
+                return;
+            }
+            if (data.type === "result") {
+                this.infoPanel.setResult(data);
+                return;
+            }
             this.gameState.update(data);
+            this.config.setBoardSize(data.Width, data.Height);
             this.gameRenderer.render(this.gameState.getState());
+            this.cursorLayer.setBoardSize(data.Width, data.Height);
+            this.viewport.sync();
+        });
+
+        // Redraw straight away when the view or the window changes, even if
+        // the game is stopped; the server sends the newly visible cells.
+        this.viewport.onChange = () => this.redraw();
+        window.addEventListener("resize", () => {
+            this.redraw();
+            this.viewport.sync();
         });
 
         // Ensure WebSocket is open before sending
@@ -33,15 +97,37 @@ class GameClient {
         }
     }
 
+    redraw() {
+        const state = this.gameState.getState();
+        if (state) this.gameRenderer.render(state);
+        this.cursorLayer.draw();
+    }
+
     sendInitMessage() {
         this.webSocketClient.send({
             type: "init",
             gameID: this.config.getGameID(),
             width: this.config.getBoardWidth(),
             height: this.config.getBoardHeight(),
-            cellSize: this.config.getCellSize()
+            cellSize: this.config.getCellSize(),
+            token: this.config.getToken(),
+            name: this.config.getPlayerName(),
+            ...this.config.getInitialFill()
         });
     }
+
+    handleRole(data) {
+        console.log("[GameClient] Joined as", data.role, "with player ID", data.playerID, "team", data.team);
+        this.playerID = data.playerID;
+        if (data.ownerToken) {
+            this.config.saveOwnerToken(data.ownerToken);
+        }
+        if (data.inviteToken) {
+            const invite = `${window.location.origin}/${this.config.getGameID()}?invite=${data.inviteToken}`;
+            console.log("[GameClient] Share this link to give edit rights:", invite);
+        }
+        this.infoPanel.setRole(data.role, data.inviteToken ? `/${this.config.getGameID()}?invite=${data.inviteToken}` : "");
+    }
 }
 
 // Start the game client asynchronously
@@ -0,0 +1,136 @@
+import {boardLayout} from './boardLayout.js';
+
+// Zoom levels in screen pixels per board cell. Below one pixel per cell the
+// server sends the density of blocks of cells instead of the cells.
+const scales = [1 / 64, 1 / 32, 1 / 16, 1 / 8, 1 / 4, 1 / 2, 1, 2, 4, 8, 16, 32];
+
+// subscribeInterval keeps panning and zooming within the server's rate
+// limit on viewport messages.
+const subscribeInterval = 100;
+
+// wholeBoard is the viewport message for no viewport at all, which is what
+// the server sends a client that has not subscribed.
+const wholeBoard = {x: 0, y: 0, width: 0, height: 0};
+
+// Viewport decides which part of the board is on screen and subscribes to
+// just that part, so boards larger than the window cost no more than the
+// window can show. It starts out fitting the whole board into the window;
+// panning or zooming switches to a free view until reset.
+export class Viewport {
+    constructor(canvasManager, webSocketClient, config) {
+        this.canvasManager = canvasManager;
+        this.webSocketClient = webSocketClient;
+        this.config = config;
+        this.fit = true;
+        this.x = 1; // board coordinates of the canvas's top-left corner
+        this.y = 1;
+        this.scale = 1;
+        this.subscribed = JSON.stringify(wholeBoard);
+        this.lastSent = 0;
+        this.timer = null;
+        this.onChange = () => {};
+    }
+
+    // layout returns the size of a cell in pixels, the canvas position of
+    // cell (1, 1) and the size of the playable area on the canvas.
+    layout(canvasWidth, canvasHeight, boardWidth, boardHeight) {
+        if (this.fit) return boardLayout(canvasWidth, canvasHeight, boardWidth, boardHeight);
+        return {
+            cellSize: this.scale,
+            offsetX: -(this.x - 1) * this.scale,
+            offsetY: -(this.y - 1) * this.scale,
+            width: (boardWidth - 2) * this.scale,
+            height: (boardHeight - 2) * this.scale
+        };
+    }
+
+    // subscription is the viewport message for what is on screen. A fitted
+    // board is only downsampled when its cells are smaller than a pixel.
+    subscription() {
+        const width = this.config.getBoardWidth();
+        const height = this.config.getBoardHeight();
+        const canvasWidth = this.canvasManager.getWidth();
+        const canvasHeight = this.canvasManager.getHeight();
+        const {cellSize} = this.layout(canvasWidth, canvasHeight, width, height);
+        const zoom = cellSize < 1 ? Math.min(Math.floor(1 / cellSize), 64) : 1;
+        if (this.fit) {
+            if (zoom === 1) return wholeBoard;
+            return {x: 0, y: 0, width: width, height: height, zoom: zoom};
+        }
+        return {
+            x: Math.floor(this.x),
+            y: Math.floor(this.y),
+            width: Math.ceil(canvasWidth / cellSize) + 1,
+            height: Math.ceil(canvasHeight / cellSize) + 1,
+            zoom: zoom
+        };
+    }
+
+    // sync subscribes to what is on screen if that has changed, at most
+    // every subscribeInterval ms. Call it after the board or window changes
+    // size.
+    sync() {
+        if (this.timer) return;
+        const wait = this.lastSent + subscribeInterval - Date.now();
+        if (wait > 0) {
+            this.timer = setTimeout(() => {
+                this.timer = null;
+                this.sync();
+            }, wait);
+            return;
+        }
+        const subscription = JSON.stringify(this.subscription());
+        if (subscription === this.subscribed) return;
+        const ws = this.webSocketClient.ws;
+        if (ws.readyState !== WebSocket.OPEN) return;
+        ws.send(JSON.stringify({type: "viewport", gameID: this.config.getGameID(), ...this.subscription()}));
+        this.subscribed = subscription;
+        this.lastSent = Date.now();
+    }
+
+    // unfit switches from fitting the board to a free view showing the same
+    // part of it.
+    unfit() {
+        if (!this.fit) return;
+        const layout = this.layout(this.canvasManager.getWidth(), this.canvasManager.getHeight(),
+            this.config.getBoardWidth(), this.config.getBoardHeight());
+        this.scale = layout.cellSize;
+        this.x = 1 - layout.offsetX / layout.cellSize;
+        this.y = 1 - layout.offsetY / layout.cellSize;
+        this.fit = false;
+    }
+
+    // pan moves the view by the given number of pixels.
+    pan(dx, dy) {
+        this.unfit();
+        this.x += dx / this.scale;
+        this.y += dy / this.scale;
+        this.changed();
+    }
+
+    // zoomAt zooms one level in (direction > 0) or out (direction < 0),
+    // keeping the board point under the canvas position (px, py) in place.
+    zoomAt(direction, px, py) {
+        this.unfit();
+        const next = direction > 0
+            ? scales.find(scale => scale > this.scale) || scales[scales.length - 1]
+            : scales.findLast(scale => scale < this.scale) || scales[0];
+        const boardX = this.x + px / this.scale;
+        const boardY = this.y + py / this.scale;
+        this.scale = next;
+        this.x = boardX - px / this.scale;
+        this.y = boardY - py / this.scale;
+        this.changed();
+    }
+
+    // reset goes back to fitting the whole board into the window.
+    reset() {
+        this.fit = true;
+        this.changed();
+    }
+
+    changed() {
+        this.onChange();
+        this.sync();
+    }
+}
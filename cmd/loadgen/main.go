@@ -0,0 +1,252 @@
+// Command loadgen load-tests a Game of Life server. It opens many WebSocket
+// connections spread across several games, sends a mix of birth, pattern and
+// randomBirth messages at a steady rate, and reports broadcast latency,
+// throughput and dropped connections.
+//
+//	go run ./cmd/loadgen -addr localhost:8080 -clients 200 -games 10 -duration 1m
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"GameOfLife/pkg/client"
+)
+
+// config holds the command line settings.
+type config struct {
+	addr     string
+	auth     string
+	insecure bool
+	clients  int
+	games    int
+	width    int
+	height   int
+	duration time.Duration
+	ramp     time.Duration
+	rate     float64
+	mix      mix
+	timeout  time.Duration
+	maxP99   time.Duration
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.addr, "addr", "localhost:8080", "server address, host:port or a ws:// or wss:// URL")
+	flag.StringVar(&cfg.auth, "auth", "", "shared secret of a server started with -auth-token")
+	flag.BoolVar(&cfg.insecure, "insecure", false, "skip TLS certificate verification, e.g. for a self-signed wss:// server")
+	flag.IntVar(&cfg.clients, "clients", 50, "number of WebSocket connections")
+	flag.IntVar(&cfg.games, "games", 5, "number of games the connections are spread across")
+	flag.IntVar(&cfg.width, "width", 200, "board width of each game")
+	flag.IntVar(&cfg.height, "height", 150, "board height of each game")
+	flag.DurationVar(&cfg.duration, "duration", 30*time.Second, "how long to send messages once every connection is open")
+	flag.DurationVar(&cfg.ramp, "ramp", 2*time.Second, "time over which the connections are opened")
+	flag.Float64Var(&cfg.rate, "rate", 2, "messages per second sent by each connection")
+	mixFlag := flag.String("mix", "birth=90,pattern=5,randomBirth=5", "relative weights of the message types sent")
+	flag.DurationVar(&cfg.timeout, "timeout", 5*time.Second, "how long to wait for a birth to be broadcast before counting it as lost")
+	flag.DurationVar(&cfg.maxP99, "max-p99", 0, "exit with status 1 if the p99 broadcast latency exceeds this (0 disables the check)")
+	flag.Parse()
+
+	m, err := parseMix(*mixFlag)
+	if err != nil {
+		log.Fatalf("[loadgen] -mix: %v", err)
+	}
+	cfg.mix = m
+	switch {
+	case cfg.clients < 1 || cfg.games < 1 || cfg.games > cfg.clients:
+		log.Fatal("[loadgen] need at least one client per game")
+	case cfg.rate <= 0:
+		log.Fatal("[loadgen] -rate must be positive")
+	case cfg.width < 3 || cfg.height < 3:
+		log.Fatal("[loadgen] boards must be at least 3x3")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	r := run(ctx, cfg)
+	r.print(os.Stdout, cfg)
+	if cfg.maxP99 > 0 && r.percentile(0.99) > cfg.maxP99 {
+		fmt.Printf("FAIL: p99 latency %v exceeds %v\n", r.percentile(0.99).Round(time.Microsecond), cfg.maxP99)
+		os.Exit(1)
+	}
+}
+
+// run connects the clients, lets them send for cfg.duration, waits for the
+// last broadcasts and returns what was measured. Cancelling ctx ends the
+// sending phase early.
+func run(ctx context.Context, cfg config) *results {
+	r := newResults()
+	gameIDs := make([]string, cfg.games)
+	for i := range gameIDs {
+		gameIDs[i] = client.NewGameID()
+	}
+
+	// The first connection of each game creates it and shares the invite
+	// token, so every other connection joins as an editor.
+	log.Printf("[loadgen] opening %d connections to %s", cfg.clients, cfg.addr)
+	workers := make([]*worker, cfg.clients)
+	invites := make([]string, cfg.games)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.clients; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		game := i % cfg.games
+		w, err := connect(ctx, cfg, gameIDs[game], invites[game], r)
+		if err != nil {
+			log.Printf("[loadgen] connection %d: %v", i, err)
+			r.dialFailed.Add(1)
+			continue
+		}
+		if i < cfg.games {
+			_, invites[game] = w.c.Tokens()
+		}
+		workers[i] = w
+		if cfg.clients > 1 {
+			time.Sleep(cfg.ramp / time.Duration(cfg.clients))
+		}
+	}
+	byPlayer := make(map[string]*worker)
+	for _, w := range workers {
+		if w != nil {
+			byPlayer[w.c.PlayerID()] = w
+		}
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+	r.start = time.Now()
+	for _, w := range workers {
+		if w == nil {
+			continue
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			w.receive(byPlayer)
+		}()
+		go func() {
+			defer wg.Done()
+			w.send(sendCtx, cfg)
+		}()
+	}
+	<-sendCtx.Done()
+	r.elapsed = time.Since(r.start)
+	log.Printf("[loadgen] sending stopped after %v, waiting %v for the last broadcasts", r.elapsed.Round(time.Millisecond), cfg.timeout)
+	time.Sleep(cfg.timeout)
+	r.finished.Store(true)
+	for _, w := range workers {
+		if w != nil {
+			w.expire(time.Now().Add(time.Hour))
+			w.c.Close()
+		}
+	}
+	wg.Wait()
+	return r
+}
+
+// connect opens one connection and joins gameID, creating the game if
+// invite is empty. It returns once the first board state has arrived, by
+// which time the server has sent the connection's role.
+func connect(ctx context.Context, cfg config, gameID, invite string, r *results) (*worker, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	c, err := client.DialWithOptions(dialCtx, cfg.addr, client.DialOptions{
+		AuthToken: cfg.auth,
+		TLSConfig: &tls.Config{InsecureSkipVerify: cfg.insecure},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = c.Init(gameID, client.InitOptions{
+		Width:    cfg.width,
+		Height:   cfg.height,
+		CellSize: 5,
+		Token:    invite,
+	})
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	select {
+	case <-c.States():
+	case err := <-c.Errors():
+		c.Close()
+		return nil, fmt.Errorf("init %s: %w", gameID, err)
+	case <-c.Done():
+		return nil, fmt.Errorf("init %s: %w", gameID, c.Err())
+	case <-dialCtx.Done():
+		c.Close()
+		return nil, fmt.Errorf("init %s: no board state received", gameID)
+	}
+	if role := c.Role(); role != client.RoleOwner && role != client.RoleEditor {
+		c.Close()
+		return nil, fmt.Errorf("joined %s as %s, want an owner or editor", gameID, role)
+	}
+	return newWorker(c, r), nil
+}
+
+// mix is the relative weight of each message type.
+type mix map[string]int
+
+// messageTypes are the message types loadgen can send, in the order the
+// report lists them.
+var messageTypes = []string{"birth", "pattern", "randomBirth"}
+
+// parseMix reads weights such as "birth=90,pattern=5,randomBirth=5".
+func parseMix(s string) (mix, error) {
+	m := make(mix)
+	total := 0
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not name=weight", part)
+		}
+		known := false
+		for _, t := range messageTypes {
+			known = known || t == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown message type %q, want one of %s", name, strings.Join(messageTypes, ", "))
+		}
+		weight, err := strconv.Atoi(value)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("weight of %s must be a non-negative integer", name)
+		}
+		m[name] = weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("at least one weight must be positive")
+	}
+	return m, nil
+}
+
+// pick chooses a message type at random according to the weights, given n
+// drawn uniformly from [0, total weight).
+func (m mix) pick(n int) string {
+	for _, t := range messageTypes {
+		if n < m[t] {
+			return t
+		}
+		n -= m[t]
+	}
+	return messageTypes[0]
+}
+
+func (m mix) total() int {
+	total := 0
+	for _, w := range m {
+		total += w
+	}
+	return total
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// results collects the measurements of a run. Every method is safe for
+// concurrent use.
+type results struct {
+	start   time.Time
+	elapsed time.Duration // length of the sending phase
+
+	dialFailed  atomic.Int64
+	states      atomic.Int64 // board states received by all connections
+	rejected    atomic.Int64 // error replies from the server
+	rateLimited atomic.Int64 // error replies due to rate limiting
+	lost        atomic.Int64 // births whose broadcast never reached the sender
+	finished    atomic.Bool  // connections closing now are closed by us
+
+	mu        sync.Mutex
+	sentBy    map[string]int // messages sent by type
+	latencies []time.Duration
+	drops     map[string]int // dropped connections by reason
+}
+
+func newResults() *results {
+	return &results{sentBy: make(map[string]int), drops: make(map[string]int)}
+}
+
+func (r *results) sent(msgType string) {
+	r.mu.Lock()
+	r.sentBy[msgType]++
+	r.mu.Unlock()
+}
+
+// observe records the latency of one birth broadcast reaching one
+// connection.
+func (r *results) observe(d time.Duration) {
+	r.mu.Lock()
+	r.latencies = append(r.latencies, d)
+	r.mu.Unlock()
+}
+
+// dropped records a connection the server closed, or that failed, before
+// the run ended.
+func (r *results) dropped(err error) {
+	reason := "closed"
+	if err != nil {
+		reason = err.Error()
+	}
+	r.mu.Lock()
+	r.drops[reason]++
+	r.mu.Unlock()
+}
+
+// percentile returns the latency below which the fraction q of the
+// observed broadcasts arrived, or 0 if none did.
+func (r *results) percentile(q float64) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	if !slices.IsSorted(r.latencies) {
+		slices.Sort(r.latencies)
+	}
+	i := int(q * float64(len(r.latencies)-1))
+	return r.latencies[i]
+}
+
+// print writes the report.
+func (r *results) print(w io.Writer, cfg config) {
+	p50, p90, p99, slowest := r.percentile(0.5), r.percentile(0.9), r.percentile(0.99), r.percentile(1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seconds := r.elapsed.Seconds()
+	if seconds == 0 {
+		seconds = 1
+	}
+
+	totalDrops := 0
+	reasons := make([]string, 0, len(r.drops))
+	for reason, n := range r.drops {
+		totalDrops += n
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	totalSent := 0
+	var byType []string
+	for _, t := range messageTypes {
+		totalSent += r.sentBy[t]
+		if r.sentBy[t] > 0 {
+			byType = append(byType, fmt.Sprintf("%d %s", r.sentBy[t], t))
+		}
+	}
+	opened := cfg.clients - int(r.dialFailed.Load())
+	states := r.states.Load()
+	round := func(d time.Duration) time.Duration { return d.Round(10 * time.Microsecond) }
+
+	fmt.Fprintf(w, "\nLoad test of %s: %d connections in %d games of %dx%d for %v\n",
+		cfg.addr, cfg.clients, cfg.games, cfg.width, cfg.height, r.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "connections   %d opened, %d failed to open, %d dropped\n", opened, r.dialFailed.Load(), totalDrops)
+	for _, reason := range reasons {
+		fmt.Fprintf(w, "              %d: %s\n", r.drops[reason], reason)
+	}
+	fmt.Fprintf(w, "sent          %d messages, %.1f/s (%s)\n", totalSent, float64(totalSent)/seconds, strings.Join(byType, ", "))
+	fmt.Fprintf(w, "rejected      %d, of which %d by rate limits\n", r.rejected.Load(), r.rateLimited.Load())
+	fmt.Fprintf(w, "board states  %d received, %.1f/s, %.1f/s per connection\n",
+		states, float64(states)/seconds, float64(states)/seconds/float64(max(opened, 1)))
+	fmt.Fprintf(w, "births        %d broadcast to their sender, %d lost\n", r.sentBy["birth"]-int(r.lost.Load()), r.lost.Load())
+	fmt.Fprintf(w, "latency       p50 %v  p90 %v  p99 %v  max %v over %d deliveries\n",
+		round(p50), round(p90), round(p99), round(slowest), len(r.latencies))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"GameOfLife/pkg/client"
+)
+
+// patternNames are the library patterns sent by "pattern" messages. They
+// are small enough to fit any board loadgen creates by default.
+var patternNames = []string{client.Glider, client.Blinker, client.Toad, client.Pulsar}
+
+// randomBirthPercentage is the density of the "randomBirth" messages, kept
+// low so the boards do not fill up.
+const randomBirthPercentage = 2
+
+// A worker drives one connection. Births carry a cell unique among the
+// worker's recent births, so when any connection in the game sees the
+// server's "action" broadcast for it, the time it was sent can be looked up.
+type worker struct {
+	c *client.Client
+	r *results
+
+	mu      sync.Mutex
+	pending map[image.Point]*birth // births sent within the timeout
+	seq     int                    // picks the cell of the next birth
+}
+
+// birth is a birth message awaiting its broadcast.
+type birth struct {
+	sent  time.Time
+	acked bool // the sender has seen the broadcast
+}
+
+func newWorker(c *client.Client, r *results) *worker {
+	return &worker{c: c, r: r, pending: make(map[image.Point]*birth)}
+}
+
+// send sends messages at cfg.rate, with random jitter so the connections do
+// not fire in lockstep, until ctx is done.
+func (w *worker) send(ctx context.Context, cfg config) {
+	interval := time.Duration(float64(time.Second) / cfg.rate)
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(interval))))
+	defer timer.Stop()
+	total := cfg.mix.total()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.c.Done():
+			return
+		case <-timer.C:
+		}
+		// Jitter each gap by up to ±25% around the interval.
+		timer.Reset(interval*3/4 + time.Duration(rand.Int63n(int64(interval/2)+1)))
+		w.expire(time.Now().Add(-cfg.timeout))
+
+		var err error
+		msgType := cfg.mix.pick(rand.Intn(total))
+		switch msgType {
+		case "birth":
+			p := w.nextCell(cfg.width, cfg.height)
+			w.mu.Lock()
+			w.pending[p] = &birth{sent: time.Now()}
+			w.mu.Unlock()
+			err = w.c.Birth(p.X, p.Y)
+		case "pattern":
+			err = w.c.Pattern(patternNames[rand.Intn(len(patternNames))])
+		case "randomBirth":
+			err = w.c.RandomBirth(randomBirthPercentage)
+		}
+		if err != nil {
+			return // the receive loop records why the connection ended
+		}
+		w.r.sent(msgType)
+	}
+}
+
+// nextCell returns the cell of the next birth, walking the playable area row
+// by row so consecutive births never share a cell.
+func (w *worker) nextCell(width, height int) image.Point {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	cols, rows := width-2, height-2
+	n := w.seq % (cols * rows)
+	w.seq++
+	return image.Pt(1+n%cols, 1+n/cols)
+}
+
+// expire forgets births sent before cutoff, counting those whose broadcast
+// never reached the sender as lost.
+func (w *worker) expire(cutoff time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for p, b := range w.pending {
+		if b.sent.Before(cutoff) {
+			if !b.acked {
+				w.r.lost.Add(1)
+			}
+			delete(w.pending, p)
+		}
+	}
+}
+
+// delivered records that the broadcast of the birth at p reached a
+// connection; own is true if that connection sent it.
+func (w *worker) delivered(p image.Point, own bool) {
+	now := time.Now()
+	w.mu.Lock()
+	b, ok := w.pending[p]
+	if ok && own {
+		b.acked = true
+	}
+	w.mu.Unlock()
+	if ok {
+		w.r.observe(now.Sub(b.sent))
+	}
+}
+
+// receive consumes everything the server sends until the connection ends.
+// byPlayer maps player IDs to the workers sending as them.
+func (w *worker) receive(byPlayer map[string]*worker) {
+	self := w.c.PlayerID()
+	states, events, errs := w.c.States(), w.c.Events(), w.c.Errors()
+	for states != nil || events != nil || errs != nil {
+		select {
+		case _, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			w.r.states.Add(1)
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if a, isAction := e.(client.ActionEvent); isAction && a.Action == "birth" {
+				if sender := byPlayer[a.PlayerID]; sender != nil {
+					sender.delivered(image.Pt(a.X, a.Y), a.PlayerID == self)
+				}
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			w.r.rejected.Add(1)
+			var serverErr *client.ServerError
+			if errors.As(err, &serverErr) && strings.HasPrefix(serverErr.Message, "rate limit exceeded") {
+				w.r.rateLimited.Add(1)
+			}
+		}
+	}
+	if !w.r.finished.Load() {
+		w.r.dropped(w.c.Err())
+	}
+}
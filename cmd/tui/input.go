@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"GameOfLife/pkg/client"
+)
+
+// command is a typed word and the message it sends, matching the table in
+// assets/eventHandler.js.
+type command struct {
+	input string
+	send  func(c *client.Client) error
+}
+
+func setBackground(color string) func(c *client.Client) error {
+	return func(c *client.Client) error { return c.SetBackgroundColor(color) }
+}
+
+// fitTerminal resizes the board to fill the terminal, keeping it centred.
+func fitTerminal(c *client.Client) error {
+	width, height, err := terminalBoardSize()
+	if err != nil {
+		return err
+	}
+	return c.Resize(width, height, client.AnchorCenter)
+}
+
+func pattern(name string) func(c *client.Client) error {
+	return func(c *client.Client) error { return c.Pattern(name) }
+}
+
+var commands = []command{
+	{"color:red", setBackground("red")},
+	{"color:blue", setBackground("blue")},
+	{"color:green", setBackground("green")},
+	{"color:reset", setBackground("#111")},
+	{"clear", (*client.Client).Clear},
+	{"random", func(c *client.Client) error { return c.RandomBirth(50) }},
+	{"stop", (*client.Client).Stop},
+	{"resume", (*client.Client).Resume},
+	{"start", (*client.Client).StartRound},
+	{"ready", (*client.Client).Ready},
+	{"fit", fitTerminal},
+	{"slide", pattern(client.Glider)},
+	{"blink", pattern(client.Blinker)},
+	{"toad", pattern(client.Toad)},
+	{"pulse", pattern(client.Pulsar)},
+	{"gun", pattern(client.GosperGliderGun)},
+	{"pent", pattern(client.RPentomino)},
+	{"snark", pattern(client.Snark)},
+	{"engine", pattern(client.TwoEngine)},
+	{"hilbert", pattern(client.DavidHilbert)},
+}
+
+// arrows maps the final byte of an arrow key's escape sequence to the
+// direction it pans.
+var arrows = map[byte][2]int{'A': {0, -1}, 'B': {0, 1}, 'C': {1, 0}, 'D': {-1, 0}}
+
+type eventKind int
+
+const (
+	eventKey eventKind = iota
+	eventCommand
+	eventClick
+	eventPan
+	eventQuit
+)
+
+// inputEvent is a decoded keypress or mouse click.
+type inputEvent struct {
+	kind    eventKind
+	buffer  string  // typed input so far, for eventKey
+	command command // matched command, for eventCommand
+	x, y    int     // zero-based terminal column and row, for eventClick, or direction, for eventPan
+	lower   bool    // click targets the lower half of the character cell
+}
+
+// readInput decodes keys and SGR mouse reports from r and sends them to
+// events. Typed characters accumulate in a buffer until they contain one of
+// the command words, the same way the browser client works.
+func readInput(r io.Reader, events chan<- inputEvent) {
+	in := bufio.NewReader(r)
+	buffer := ""
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			events <- inputEvent{kind: eventQuit}
+			return
+		}
+		switch {
+		case b == 0x03 || b == 0x04: // Ctrl-C, Ctrl-D
+			events <- inputEvent{kind: eventQuit}
+			return
+		case b == 0x1b:
+			if ev, ok := readEscape(in); ok {
+				events <- ev
+			}
+			continue
+		case b == 0x7f || b == 0x08:
+			if len(buffer) > 0 {
+				buffer = buffer[:len(buffer)-1]
+			}
+		case b >= 0x20 && b < 0x7f:
+			buffer += strings.ToLower(string(b))
+		default:
+			continue
+		}
+
+		matched := false
+		for _, cmd := range commands {
+			if strings.Contains(buffer, cmd.input) {
+				events <- inputEvent{kind: eventCommand, command: cmd}
+				buffer = ""
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if len(buffer) > 20 {
+			buffer = buffer[len(buffer)-10:]
+		}
+		events <- inputEvent{kind: eventKey, buffer: buffer}
+	}
+}
+
+// readEscape consumes an escape sequence after ESC. SGR mouse presses
+// ("ESC [ < b ; x ; y M") become clicks: the left button targets the upper
+// half of a character cell and the right button the lower half. Arrow keys
+// pan the view. Everything else is discarded.
+func readEscape(in *bufio.Reader) (inputEvent, bool) {
+	if in.Buffered() == 0 {
+		return inputEvent{}, false
+	}
+	if b, _ := in.ReadByte(); b != '[' {
+		return inputEvent{}, false
+	}
+	var seq []byte
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return inputEvent{}, false
+		}
+		seq = append(seq, b)
+		if b >= 0x40 && b <= 0x7e && !(len(seq) == 1 && b == '<') {
+			break
+		}
+	}
+	if len(seq) == 1 {
+		if d, ok := arrows[seq[0]]; ok {
+			return inputEvent{kind: eventPan, x: d[0], y: d[1]}, true
+		}
+	}
+	if len(seq) < 2 || seq[0] != '<' || seq[len(seq)-1] != 'M' {
+		return inputEvent{}, false
+	}
+	parts := strings.Split(string(seq[1:len(seq)-1]), ";")
+	if len(parts) != 3 {
+		return inputEvent{}, false
+	}
+	button, err1 := strconv.Atoi(parts[0])
+	col, err2 := strconv.Atoi(parts[1])
+	row, err3 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return inputEvent{}, false
+	}
+	// Bit 5 marks motion while a button is held, so dragging draws too.
+	switch button &^ 32 {
+	case 0:
+		return inputEvent{kind: eventClick, x: col - 1, y: row - 1}, true
+	case 2:
+		return inputEvent{kind: eventClick, x: col - 1, y: row - 1, lower: true}, true
+	}
+	return inputEvent{}, false
+}
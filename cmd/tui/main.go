@@ -0,0 +1,157 @@
+// Command tui plays Game of Life in a terminal over the server's WebSocket
+// protocol. Live cells are drawn with Unicode half-blocks, so every character
+// cell shows two board rows.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+
+	"GameOfLife/pkg/client"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "server address")
+	gameID := flag.String("game", "", "game ID to join (a new one is generated if empty)")
+	cellSize := flag.Int("cellsize", 5, "cell size reported to the server for image exports")
+	token := flag.String("token", "", "invite or owner token granting edit rights in an existing game")
+	name := flag.String("name", "", "player name shown to others")
+	auth := flag.String("auth", "", "shared secret of a server started with -auth-token")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification, e.g. for a self-signed wss:// server")
+	flag.Parse()
+
+	if *gameID == "" {
+		*gameID = client.NewGameID()
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		log.Fatal("[TUI] stdin is not a terminal")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	c, err := client.DialWithOptions(ctx, *addr, client.DialOptions{
+		AuthToken: *auth,
+		TLSConfig: &tls.Config{InsecureSkipVerify: *insecure},
+	})
+	cancel()
+	if err != nil {
+		log.Fatalf("[TUI] %v", err)
+	}
+	defer c.Close()
+	if *name != "" {
+		c.SetName(*name)
+	}
+
+	width, height, err := terminalBoardSize()
+	if err != nil {
+		log.Fatalf("[TUI] Terminal size: %v", err)
+	}
+	err = c.Init(*gameID, client.InitOptions{
+		Width:    width,
+		Height:   height,
+		CellSize: *cellSize,
+		Token:    *token,
+	})
+	if err != nil {
+		log.Fatalf("[TUI] Send init: %v", err)
+	}
+	shown := view{x: 1, y: 1}
+	if err := shown.subscribe(c); err != nil {
+		log.Fatalf("[TUI] Send viewport: %v", err)
+	}
+
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		log.Fatalf("[TUI] Raw mode: %v", err)
+	}
+	screen := newScreen(os.Stdout)
+	screen.start()
+	defer func() {
+		screen.stop()
+		term.Restore(int(os.Stdin.Fd()), oldState)
+		fmt.Printf("Left game %s\n", *gameID)
+	}()
+
+	events := make(chan inputEvent)
+	go readInput(os.Stdin, events)
+
+	var current *client.State
+	status := "Type a command (slide, gun, clear, random, stop, resume...), left/right click to add cells, arrows to pan, Ctrl-C quits"
+	for {
+		select {
+		case state := <-c.States():
+			if state != nil {
+				current = state
+			}
+		case err := <-c.Errors():
+			if err != nil {
+				status = err.Error()
+			}
+		case ev := <-c.Events():
+			switch ev := ev.(type) {
+			case client.ActionEvent:
+				status = ev.Name + ": " + ev.Action
+				if ev.Action == "resize" {
+					status += fmt.Sprintf(" to %dx%d", ev.Width, ev.Height)
+				}
+			case client.ChatEvent:
+				status = ev.Name + " says: " + ev.Text
+			case client.NoticeEvent:
+				status = "Server notice: " + ev.Text
+			case client.RoundEvent:
+				status = fmt.Sprintf("Round %d: %s", ev.Round, ev.Phase)
+			case client.ResultEvent:
+				status = fmt.Sprintf("Round %d drawn (%d - %d)", ev.Round, ev.Scores[0], ev.Scores[1])
+				if ev.Winner > 0 {
+					status = fmt.Sprintf("Round %d won by team %d (%d - %d)", ev.Round, ev.Winner, ev.Scores[0], ev.Scores[1])
+				}
+			}
+		case <-c.Done():
+			screen.stop()
+			term.Restore(int(os.Stdin.Fd()), oldState)
+			log.Fatalf("[TUI] Connection closed: %v", c.Err())
+		case ev := <-events:
+			switch ev.kind {
+			case eventQuit:
+				return
+			case eventClick:
+				// Column c and row r show board cells (x+c, y+2r) and (x+c, y+2r+1)
+				// of the view.
+				x, y := shown.x+ev.x, shown.y+ev.y*2
+				if ev.lower {
+					y++
+				}
+				c.Birth(x, y)
+			case eventPan:
+				if panned := shown.pan(ev.x, ev.y, current); panned != shown {
+					shown = panned
+					shown.subscribe(c)
+				}
+			case eventCommand:
+				ev.command.send(c)
+				status = "Sent " + ev.command.input
+			case eventKey:
+				status = "> " + ev.buffer
+			}
+		}
+		screen.draw(current, shown, *gameID, c.Role(), status)
+	}
+}
+
+// terminalBoardSize returns the board size that fills the terminal: one
+// board column per terminal column and two board rows per terminal row,
+// leaving the last row for the status line. The +2 accounts for the dead
+// border the server keeps around the playable area.
+func terminalBoardSize() (width, height int, err error) {
+	cols, rows, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0, 0, err
+	}
+	return cols + 2, (rows-1)*2 + 2, nil
+}
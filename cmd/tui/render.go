@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"GameOfLife/pkg/client"
+)
+
+// screen draws board states onto an ANSI terminal.
+type screen struct {
+	out *bufio.Writer
+}
+
+func newScreen(w io.Writer) *screen {
+	return &screen{out: bufio.NewWriter(w)}
+}
+
+// start switches to the alternate screen, hides the cursor and enables
+// button and drag mouse reporting in SGR format.
+func (s *screen) start() {
+	s.out.WriteString("\x1b[?1049h\x1b[?25l\x1b[?1002h\x1b[?1006h")
+	s.out.Flush()
+}
+
+// stop undoes everything start enabled.
+func (s *screen) stop() {
+	s.out.WriteString("\x1b[?1006l\x1b[?1002l\x1b[0m\x1b[?25h\x1b[?1049l")
+	s.out.Flush()
+}
+
+// draw renders the part of the board in view followed by a status line.
+func (s *screen) draw(state *client.State, v view, gameID, role, status string) {
+	cols, rows, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return
+	}
+	s.out.WriteString("\x1b[H")
+	live := 0
+	if state != nil {
+		fg := ansiColor(state.Color, 38)
+		bg := ansiColor(state.BackgroundColor, 48)
+		for r := 0; r < rows-1; r++ {
+			s.out.WriteString(fg + bg)
+			for c := 0; c < cols; c++ {
+				x, y := v.x+c, v.y+r*2
+				inside := x < state.Width-1
+				top := inside && y < state.Height-1 && state.Alive(x, y)
+				bottom := inside && y+1 < state.Height-1 && state.Alive(x, y+1)
+				switch {
+				case top && bottom:
+					s.out.WriteString("█")
+					live += 2
+				case top:
+					s.out.WriteString("▀")
+					live++
+				case bottom:
+					s.out.WriteString("▄")
+					live++
+				default:
+					s.out.WriteByte(' ')
+				}
+			}
+			s.out.WriteString("\x1b[0m\r\n")
+		}
+	} else {
+		s.out.WriteString("\x1b[2J")
+	}
+
+	line := fmt.Sprintf(" %s | %s | live: %d", gameID, role, live)
+	if state != nil && state.Stopped {
+		line += " | stopped"
+	}
+	line += " | " + status
+	if len([]rune(line)) > cols {
+		line = string([]rune(line)[:cols])
+	}
+	s.out.WriteString("\x1b[7m" + line + strings.Repeat(" ", max(cols-len([]rune(line)), 0)) + "\x1b[0m")
+	s.out.Flush()
+}
+
+// ansiColor converts a "#rgb" or "#rrggbb" colour into a 24-bit SGR
+// sequence; base is 38 for foreground or 48 for background. The colour names
+// the browser client sends map to the basic palette, anything else keeps the
+// terminal default.
+func ansiColor(c string, base int) string {
+	switch strings.ToLower(c) {
+	case "red":
+		return fmt.Sprintf("\x1b[%dm", base-7)
+	case "green":
+		return fmt.Sprintf("\x1b[%dm", base-6)
+	case "blue":
+		return fmt.Sprintf("\x1b[%dm", base-4)
+	}
+	if !strings.HasPrefix(c, "#") {
+		return ""
+	}
+	hex := c[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return ""
+	}
+	return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", base, v>>16&0xff, v>>8&0xff, v&0xff)
+}
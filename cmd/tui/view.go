@@ -0,0 +1,39 @@
+package main
+
+import "GameOfLife/pkg/client"
+
+// view is the part of the board shown in the terminal. The top-left
+// character shows board cells (x, y) and (x, y+1).
+type view struct {
+	x, y int
+}
+
+// size returns the number of board columns and rows the terminal shows.
+func (v view) size() (cols, rows int, err error) {
+	width, height, err := terminalBoardSize()
+	return width - 2, height - 2, err
+}
+
+// subscribe asks the server to send only the cells the terminal shows, so
+// boards larger than the terminal cost no more than ones that fit.
+func (v view) subscribe(c *client.Client) error {
+	cols, rows, err := v.size()
+	if err != nil {
+		return err
+	}
+	return c.SetViewport(client.Viewport{X: v.x, Y: v.y, Width: cols, Height: rows, Zoom: 1})
+}
+
+// pan moves the view a quarter of the terminal in the direction (dx, dy),
+// stopping at the edges of state's board.
+func (v view) pan(dx, dy int, state *client.State) view {
+	cols, rows, err := v.size()
+	if err != nil || state == nil {
+		return v
+	}
+	v.x += dx * max(cols/4, 1)
+	v.y += dy * max(rows/4&^1, 2) // whole characters, two rows each
+	v.x = max(min(v.x, state.Width-1-cols), 1)
+	v.y = max(min(v.y, state.Height-1-rows), 1)
+	return v
+}
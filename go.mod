@@ -2,4 +2,9 @@ module GameOfLife
 
 go 1.24
 
-require github.com/gorilla/websocket v1.5.3
+require (
+	github.com/gorilla/websocket v1.5.3
+	golang.org/x/term v0.30.0
+)
+
+require golang.org/x/sys v0.31.0 // indirect
@@ -0,0 +1,886 @@
+// Package client is a Go client for the Game of Life server's WebSocket
+// protocol. It hides the JSON message shapes behind typed methods and
+// delivers decoded board states on a channel.
+//
+//	c, err := client.Dial(ctx, "localhost:8080")
+//	if err != nil { ... }
+//	defer c.Close()
+//	if err := c.Init("game_demo", client.InitOptions{Width: 80, Height: 60, CellSize: 10}); err != nil { ... }
+//	c.Pattern(client.Glider)
+//	for state := range c.States() { ... }
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// Library patterns understood by the "pattern" message.
+const (
+	Glider          = "glider"
+	Blinker         = "blinker"
+	Toad            = "toad"
+	Pulsar          = "pulsar"
+	GosperGliderGun = "gosper_glider_gun"
+	RPentomino      = "r_pentomino"
+	Snark           = "snark"
+	TwoEngine       = "2_engine"
+	DavidHilbert    = "david_hilbert"
+)
+
+// Initial fills accepted by InitOptions.Fill.
+const (
+	FillEmpty   = "empty"
+	FillRandom  = "random"
+	FillC2      = "c2"
+	FillC4      = "c4"
+	FillD8      = "d8"
+	FillPattern = "pattern"
+	FillRLE     = "rle"
+)
+
+// Game modes accepted by InitOptions.Mode. Immigration has two teams and
+// QuadLife four; each player is put on a team and newborn cells take the
+// colour of most of their parents. Territory is a two player game played in
+// rounds, see StartRound.
+const (
+	ModeClassic     = "classic"
+	ModeImmigration = "immigration"
+	ModeQuadLife    = "quadlife"
+	ModeTerritory   = "territory"
+)
+
+// Anchors accepted by Resize: the part of the board that stays in place
+// while the opposite edges move.
+const (
+	AnchorCenter      = "center"
+	AnchorTopLeft     = "top-left"
+	AnchorTopRight    = "top-right"
+	AnchorBottomLeft  = "bottom-left"
+	AnchorBottomRight = "bottom-right"
+)
+
+// Phases of a territory round.
+const (
+	PhaseWaiting  = "waiting"
+	PhaseSetup    = "setup"
+	PhaseRunning  = "running"
+	PhaseFinished = "finished"
+)
+
+// bufferSize is the capacity of the States, Events and Errors channels.
+const bufferSize = 16
+
+// ErrNoGame is returned by commands sent before Init or Join.
+var ErrNoGame = errors.New("client: no game joined")
+
+// State is one board state broadcast by the server. Board holds the raw cell
+// bytes: values of protocol.Alive and above are live cells, the remainder is
+// the number of live neighbours.
+//
+// After SetViewport, Board and Owners cover only View, while Width and
+// Height remain the size of the whole board. When the viewport is zoomed
+// out, Board is nil and Density holds one byte per block of View.Zoom x
+// View.Zoom cells, from 0 for an empty block to 255 for a full one; Owners
+// then holds the team owning most of each block.
+type State struct {
+	Board           [][]uint8
+	Width           int
+	Height          int
+	CellSize        int
+	Color           string
+	BackgroundColor string
+	Interval        int64 // shortest time between generations, in nanoseconds
+	Stopped         bool
+	Mode            string
+	Rule            string    // Life-like rule in B/S notation, e.g. "B3/S23"
+	Owners          [][]uint8 // team of each live cell, nil in classic games
+	TeamColors      []string  // colour of each team, indexed by team - 1
+	View            *Viewport // part of the board sent, nil for all of it
+	Density         [][]uint8 // share of live cells per block, when zoomed out
+}
+
+// Viewport is a rectangle of the board in cell coordinates, see SetViewport.
+type Viewport struct {
+	X, Y          int
+	Width, Height int
+	Zoom          int // cells along each side of a block, 1 for single cells
+}
+
+// index maps the board cell at (x, y) to its row and column in Board, or
+// in Owners and Density when zoomed out.
+func (s *State) index(x, y int) (row, col int) {
+	if s.View == nil {
+		return y, x
+	}
+	row, col = y-s.View.Y, x-s.View.X
+	if s.View.Zoom > 1 && row >= 0 && col >= 0 {
+		row, col = row/s.View.Zoom, col/s.View.Zoom
+	}
+	return row, col
+}
+
+// Owner returns the team owning the cell at (x, y), or 0 if there is none or
+// the cell is outside the state's view. When zoomed out it returns the
+// owner of the cell's block.
+func (s *State) Owner(x, y int) int {
+	row, col := s.index(x, y)
+	if row < 0 || row >= len(s.Owners) || col < 0 || col >= len(s.Owners[row]) {
+		return 0
+	}
+	return int(s.Owners[row][col])
+}
+
+// Alive reports whether the cell at (x, y) is alive. Cells outside the
+// state's view, and every cell of a zoomed out state, report false.
+func (s *State) Alive(x, y int) bool {
+	row, col := s.index(x, y)
+	if row < 0 || row >= len(s.Board) || col < 0 || col >= len(s.Board[row]) {
+		return false
+	}
+	return s.Board[row][col] >= protocol.Alive
+}
+
+// LiveCells counts the live cells in the state: on the whole board, or in
+// its view after SetViewport.
+func (s *State) LiveCells() int {
+	n := 0
+	for _, row := range s.Board {
+		for _, cell := range row {
+			if cell >= protocol.Alive {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// ServerError is an error reply sent by the server for a rejected message.
+type ServerError struct {
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return "server: " + e.Message
+}
+
+// Event is a non-board message from the server, such as PresenceEvent,
+// ActionEvent or CursorEvent.
+type Event interface {
+	event()
+}
+
+// Player is one entry of a game's presence list.
+type Player struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+	Team int    `json:"team"`
+}
+
+// PresenceEvent lists the players in the game after someone joins, leaves
+// or changes their name.
+type PresenceEvent struct {
+	GameID  string
+	Players []Player
+}
+
+// ActionEvent reports which player changed the board. Only the fields that
+// apply to Action are set.
+type ActionEvent struct {
+	Action     string // message type, e.g. "birth" or "pattern"
+	PlayerID   string
+	Name       string
+	X, Y       int
+	Pattern    string
+	Percentage int
+	Color      string
+	Width      int // new board size of a resize
+	Height     int
+	Anchor     string
+}
+
+// Score is one player's population in a competitive game.
+type Score struct {
+	PlayerID string `json:"playerID"`
+	Name     string `json:"name"`
+	Team     int    `json:"team"`
+	Color    string `json:"color"`
+	Cells    int    `json:"cells"`
+}
+
+// ScoresEvent is sent after every generation of a competitive game. Teams
+// holds the live cell count of each team, indexed by team - 1, and Scores
+// is sorted from the highest population down.
+type ScoresEvent struct {
+	GameID string
+	Teams  []int
+	Scores []Score
+}
+
+// RoundEvent describes the current round of a territory game. Remaining and
+// Ready are indexed by team - 1; SecondsLeft counts down the setup phase.
+type RoundEvent struct {
+	GameID      string
+	Phase       string // one of the Phase constants
+	Round       int
+	Generation  int
+	Generations int
+	Budget      int
+	Remaining   [2]int
+	Ready       [2]bool
+	SecondsLeft int
+}
+
+// ResultEvent announces the end of a territory round. Winner is the winning
+// team, or 0 for a draw, and Scores holds each team's final population.
+type ResultEvent struct {
+	GameID     string
+	Round      int
+	Winner     int
+	WinnerID   string
+	WinnerName string
+	Scores     [2]int
+}
+
+// CursorEvent is another player's pointer position, relayed as they move it.
+type CursorEvent struct {
+	PlayerID string
+	Name     string
+	Team     int
+	X, Y     int
+}
+
+// SelectionEvent is a rectangle another player has selected. A zero Width
+// or Height means the selection was cleared.
+type SelectionEvent struct {
+	PlayerID      string
+	Name          string
+	Team          int
+	X, Y          int
+	Width, Height int
+}
+
+// ChatEvent is a chat message from a player in the game, including this one.
+type ChatEvent struct {
+	PlayerID string    `json:"playerID"`
+	Name     string    `json:"name"`
+	Text     string    `json:"text"`
+	Time     time.Time `json:"time"`
+}
+
+// ChatHistoryEvent carries the game's recent chat, oldest first, and is sent
+// after joining a game that has any.
+type ChatHistoryEvent struct {
+	Messages []ChatEvent
+}
+
+// NoticeEvent is an announcement from the server's operators, sent to every
+// player.
+type NoticeEvent struct {
+	Text string
+	Time time.Time
+}
+
+func (PresenceEvent) event()    {}
+func (ActionEvent) event()      {}
+func (ScoresEvent) event()      {}
+func (RoundEvent) event()       {}
+func (ResultEvent) event()      {}
+func (CursorEvent) event()      {}
+func (SelectionEvent) event()   {}
+func (ChatEvent) event()        {}
+func (ChatHistoryEvent) event() {}
+func (NoticeEvent) event()      {}
+
+// InitOptions describes the game created by Init when the game ID is new.
+// Apart from Token they are ignored when the game already exists.
+type InitOptions struct {
+	Width    int
+	Height   int
+	CellSize int
+	Fill     string // one of the Fill constants, random by default
+	Density  int    // live cell percentage for random and symmetric fills, server default if zero
+	Pattern  string // library pattern for FillPattern
+	RLE      string // run-length encoded pattern for FillRLE
+	Mode     string // one of the Mode constants, classic by default
+	Token    string // owner or invite token when joining an existing game
+
+	// Territory settings; zero leaves the server default.
+	Budget       int // cells each player may place per round
+	Generations  int // generations simulated per round
+	SetupSeconds int // length of the setup phase
+}
+
+// Roles the server assigns in a game. Spectators cannot change the board.
+const (
+	RoleOwner     = "owner"
+	RoleEditor    = "editor"
+	RoleSpectator = "spectator"
+)
+
+// Client is a connection to the server playing a single game at a time.
+type Client struct {
+	conn   *websocket.Conn
+	states chan *State
+	events chan Event
+	errs   chan error
+
+	writeMu sync.Mutex
+	gameID  string
+	name    string
+
+	roleMu      sync.Mutex
+	playerID    string
+	role        string
+	team        int
+	ownerToken  string
+	inviteToken string
+
+	done    chan struct{}
+	readErr error
+}
+
+// DialOptions configures DialWithOptions.
+type DialOptions struct {
+	// AuthToken is the server's shared secret, sent as a Bearer token, for
+	// servers started with -auth-token.
+	AuthToken string
+	// TLSConfig is used for wss:// URLs, e.g. to trust a self-signed
+	// certificate. Nil uses the system roots.
+	TLSConfig *tls.Config
+}
+
+// Dial connects to the server. addr is either a host:port, in which case
+// the default ws://host:port/ws endpoint is used, or a full ws:// or wss://
+// URL.
+func Dial(ctx context.Context, addr string) (*Client, error) {
+	return DialWithOptions(ctx, addr, DialOptions{})
+}
+
+// DialWithOptions is Dial for servers that need authentication or a custom
+// TLS configuration.
+func DialWithOptions(ctx context.Context, addr string, opts DialOptions) (*Client, error) {
+	if !strings.Contains(addr, "://") {
+		addr = (&url.URL{Scheme: "ws", Host: addr, Path: "/ws"}).String()
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = opts.TLSConfig
+	header := http.Header{}
+	if opts.AuthToken != "" {
+		header.Set("Authorization", "Bearer "+opts.AuthToken)
+	}
+	conn, resp, err := dialer.DialContext(ctx, addr, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("client: dial %s: %w (%s)", addr, err, resp.Status)
+		}
+		return nil, fmt.Errorf("client: dial %s: %w", addr, err)
+	}
+	c := &Client{
+		conn:   conn,
+		states: make(chan *State, bufferSize),
+		events: make(chan Event, bufferSize),
+		errs:   make(chan error, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// NewGameID returns a fresh game ID in the same format as the browser client.
+func NewGameID() string {
+	return "game_" + strconv.FormatInt(time.Now().UnixMilli(), 36) + "_" + strconv.FormatInt(rand.Int63n(1<<25), 36)
+}
+
+// States delivers every board state the server sends for the joined game.
+// When the consumer falls behind, the oldest buffered state is dropped, as
+// each state is a complete snapshot of the board. The channel is closed when
+// the connection ends.
+func (c *Client) States() <-chan *State {
+	return c.states
+}
+
+// Events delivers presence updates, player actions and other non-board
+// messages. Events are dropped when the buffer is full. The channel is
+// closed when the connection ends.
+func (c *Client) Events() <-chan Event {
+	return c.events
+}
+
+// Errors delivers ServerError replies for rejected messages. Errors are
+// dropped when the buffer is full. The channel is closed when the
+// connection ends.
+func (c *Client) Errors() <-chan error {
+	return c.errs
+}
+
+// Done is closed when the connection ends. Err then reports why.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error that ended the connection, or nil while it is open.
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.readErr
+	default:
+		return nil
+	}
+}
+
+// GameID returns the game the client last initialised or joined.
+func (c *Client) GameID() string {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.gameID
+}
+
+// PlayerID returns the ID the server assigned to this connection, or "" if
+// no game has been joined yet.
+func (c *Client) PlayerID() string {
+	c.roleMu.Lock()
+	defer c.roleMu.Unlock()
+	return c.playerID
+}
+
+// Role returns the role the server assigned in the current game, or "" if
+// none has been received yet.
+func (c *Client) Role() string {
+	c.roleMu.Lock()
+	defer c.roleMu.Unlock()
+	return c.role
+}
+
+// Team returns the player's team in a competitive game, or 0.
+func (c *Client) Team() int {
+	c.roleMu.Lock()
+	defer c.roleMu.Unlock()
+	return c.team
+}
+
+// Tokens returns the owner and invite tokens of a game this client created.
+// Both are empty unless the client is the owner.
+func (c *Client) Tokens() (ownerToken, inviteToken string) {
+	c.roleMu.Lock()
+	defer c.roleMu.Unlock()
+	return c.ownerToken, c.inviteToken
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	c.writeMu.Lock()
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	c.writeMu.Unlock()
+	return c.conn.Close()
+}
+
+// Init joins gameID, creating it with opts if it does not exist yet.
+func (c *Client) Init(gameID string, opts InitOptions) error {
+	msg := map[string]interface{}{
+		"type":     protocol.TypeInit,
+		"gameID":   gameID,
+		"width":    opts.Width,
+		"height":   opts.Height,
+		"cellSize": opts.CellSize,
+	}
+	if opts.Fill != "" {
+		msg["fill"] = opts.Fill
+	}
+	if opts.Density != 0 {
+		msg["density"] = opts.Density
+	}
+	if opts.Pattern != "" {
+		msg["pattern"] = opts.Pattern
+	}
+	if opts.RLE != "" {
+		msg["rle"] = opts.RLE
+	}
+	if opts.Mode != "" {
+		msg["mode"] = opts.Mode
+	}
+	if opts.Token != "" {
+		msg["token"] = opts.Token
+	}
+	if opts.Budget != 0 {
+		msg["budget"] = opts.Budget
+	}
+	if opts.Generations != 0 {
+		msg["generations"] = opts.Generations
+	}
+	if opts.SetupSeconds != 0 {
+		msg["setupSeconds"] = opts.SetupSeconds
+	}
+	return c.sendAs(gameID, msg)
+}
+
+// SetName sets the player name shown to others. It is sent with every
+// later Init and Join, and immediately if a game has been joined.
+func (c *Client) SetName(name string) error {
+	c.writeMu.Lock()
+	c.name = name
+	joined := c.gameID != ""
+	c.writeMu.Unlock()
+	if !joined {
+		return nil
+	}
+	return c.send(map[string]interface{}{"type": protocol.TypeSetName, "name": name})
+}
+
+// Join joins an existing game, as an editor or owner if token matches one
+// of the game's tokens and as a spectator otherwise. The server replies with
+// a ServerError if the game does not exist.
+func (c *Client) Join(gameID, token string) error {
+	msg := map[string]interface{}{"type": protocol.TypeJoin, "gameID": gameID}
+	if token != "" {
+		msg["token"] = token
+	}
+	return c.sendAs(gameID, msg)
+}
+
+// Birth brings the cell at (x, y) to life.
+func (c *Client) Birth(x, y int) error {
+	return c.send(map[string]interface{}{"type": protocol.TypeBirth, "x": x, "y": y})
+}
+
+// Stop pauses the simulation.
+func (c *Client) Stop() error {
+	return c.send(map[string]interface{}{"type": protocol.TypeStop})
+}
+
+// Resume restarts a paused simulation.
+func (c *Client) Resume() error {
+	return c.send(map[string]interface{}{"type": protocol.TypeResume})
+}
+
+// SetBackgroundColor changes the board's background colour.
+func (c *Client) SetBackgroundColor(color string) error {
+	return c.send(map[string]interface{}{"type": protocol.TypeSetBackgroundColor, "color": color})
+}
+
+// Clear kills every cell on the board.
+func (c *Client) Clear() error {
+	return c.send(map[string]interface{}{"type": protocol.TypeClear})
+}
+
+// RandomBirth brings each dead cell to life with the given percentage chance.
+func (c *Client) RandomBirth(percentage int) error {
+	return c.send(map[string]interface{}{"type": protocol.TypeRandomBirth, "percentage": percentage})
+}
+
+// Pattern places one of the library patterns on the board.
+func (c *Client) Pattern(name string) error {
+	return c.send(map[string]interface{}{"type": protocol.TypePattern, "pattern": name})
+}
+
+// Resize grows or shrinks the board to width x height, keeping live cells
+// in place relative to anchor; an empty anchor means AnchorCenter. Only the
+// owner may resize, within the server's size limits, and not in territory
+// games.
+func (c *Client) Resize(width, height int, anchor string) error {
+	msg := map[string]interface{}{"type": protocol.TypeResize, "width": width, "height": height}
+	if anchor != "" {
+		msg["anchor"] = anchor
+	}
+	return c.send(msg)
+}
+
+// StartRound opens the setup phase of the next territory round. Only the
+// owner may start a round, and both players must be present.
+func (c *Client) StartRound() error {
+	return c.send(map[string]interface{}{"type": protocol.TypeStartRound})
+}
+
+// Ready tells the server the player has finished placing cells. The round
+// runs once both players are ready or the setup phase times out.
+func (c *Client) Ready() error {
+	return c.send(map[string]interface{}{"type": protocol.TypeReady})
+}
+
+// Cursor shares the player's pointer position with the other players. The
+// server drops cursor messages sent faster than about 20 per second.
+func (c *Client) Cursor(x, y int) error {
+	return c.send(map[string]interface{}{"type": protocol.TypeCursor, "x": x, "y": y})
+}
+
+// Selection shares a selected rectangle with the other players. A zero width
+// or height clears the selection.
+func (c *Client) Selection(x, y, width, height int) error {
+	return c.send(map[string]interface{}{"type": protocol.TypeSelection, "x": x, "y": y, "width": width, "height": height})
+}
+
+// SetViewport asks the server to send only the cells inside v from now on,
+// or, with a zoom above 1, the density of each block of v.Zoom x v.Zoom
+// cells. The server replies with a state straight away, so panning works
+// while the game is stopped. A zero Viewport subscribes to the whole board
+// again, as does joining another game.
+func (c *Client) SetViewport(v Viewport) error {
+	msg := map[string]interface{}{"type": protocol.TypeViewport, "x": v.X, "y": v.Y, "width": v.Width, "height": v.Height}
+	if v.Zoom > 0 {
+		msg["zoom"] = v.Zoom
+	}
+	return c.send(msg)
+}
+
+// Chat sends a message to everyone in the game. The server rejects empty
+// messages, messages over 500 characters and more than two a second.
+func (c *Client) Chat(text string) error {
+	return c.send(map[string]interface{}{"type": protocol.TypeChat, "text": text})
+}
+
+// send writes msg for the current game.
+func (c *Client) send(msg map[string]interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.gameID == "" {
+		return ErrNoGame
+	}
+	msg["gameID"] = c.gameID
+	return c.conn.WriteJSON(msg)
+}
+
+// sendAs writes an init or join msg and makes gameID the current game.
+func (c *Client) sendAs(gameID string, msg map[string]interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.gameID = gameID
+	if c.name != "" {
+		msg["name"] = c.name
+	}
+	return c.conn.WriteJSON(msg)
+}
+
+// wireMessage is the union of every message the server sends.
+type wireMessage struct {
+	Type        string `json:"type"`
+	Message     string `json:"message"`
+	Role        string `json:"role"`
+	OwnerToken  string `json:"ownerToken"`
+	InviteToken string `json:"inviteToken"`
+
+	GameID     string   `json:"gameID"`
+	Players    []Player `json:"players"`
+	Action     string   `json:"action"`
+	PlayerID   string   `json:"playerID"`
+	Name       string   `json:"name"`
+	X          int      `json:"x"`
+	Y          int      `json:"y"`
+	Pattern    string   `json:"pattern"`
+	Percentage int      `json:"percentage"`
+	NewColor   string   `json:"color"`
+	Anchor     string   `json:"anchor"`
+
+	Board           []string
+	Width           int
+	Height          int
+	CellSize        int
+	Color           string
+	BackgroundColor string
+	Interval        int64
+	Stopped         bool
+	Mode            string
+	Rule            string
+	Owners          []string
+	TeamColors      []string
+	View            *protocol.Viewport
+	Density         []string
+
+	Team   int             `json:"team"`
+	Teams  []int           `json:"teams"`
+	Scores json.RawMessage `json:"scores"` // []Score or, in a result, [2]int
+
+	Phase       string  `json:"phase"`
+	Round       int     `json:"round"`
+	Generation  int     `json:"generation"`
+	Generations int     `json:"generations"`
+	Budget      int     `json:"budget"`
+	Remaining   [2]int  `json:"remaining"`
+	Ready       [2]bool `json:"ready"`
+	SecondsLeft int     `json:"secondsLeft"`
+	Winner      int     `json:"winner"`
+	WinnerID    string  `json:"winnerID"`
+	WinnerName  string  `json:"winnerName"`
+
+	Text     string      `json:"text"`
+	Time     time.Time   `json:"time"`
+	Messages []ChatEvent `json:"messages"`
+}
+
+func (c *Client) readLoop() {
+	defer func() {
+		close(c.states)
+		close(c.events)
+		close(c.errs)
+		close(c.done)
+	}()
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.readErr = err
+			return
+		}
+		var msg wireMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case protocol.TypeRole:
+			c.roleMu.Lock()
+			c.playerID, c.role, c.team = msg.PlayerID, msg.Role, msg.Team
+			c.ownerToken, c.inviteToken = msg.OwnerToken, msg.InviteToken
+			c.roleMu.Unlock()
+		case protocol.TypePresence:
+			c.emit(PresenceEvent{GameID: msg.GameID, Players: msg.Players})
+		case protocol.TypeAction:
+			c.emit(ActionEvent{
+				Action:     msg.Action,
+				PlayerID:   msg.PlayerID,
+				Name:       msg.Name,
+				X:          msg.X,
+				Y:          msg.Y,
+				Pattern:    msg.Pattern,
+				Percentage: msg.Percentage,
+				Color:      msg.NewColor,
+				Width:      msg.Width,
+				Height:     msg.Height,
+				Anchor:     msg.Anchor,
+			})
+		case protocol.TypeScores:
+			var scores []Score
+			json.Unmarshal(msg.Scores, &scores)
+			c.emit(ScoresEvent{GameID: msg.GameID, Teams: msg.Teams, Scores: scores})
+		case protocol.TypeRound:
+			c.emit(RoundEvent{
+				GameID:      msg.GameID,
+				Phase:       msg.Phase,
+				Round:       msg.Round,
+				Generation:  msg.Generation,
+				Generations: msg.Generations,
+				Budget:      msg.Budget,
+				Remaining:   msg.Remaining,
+				Ready:       msg.Ready,
+				SecondsLeft: msg.SecondsLeft,
+			})
+		case protocol.TypeResult:
+			var scores [2]int
+			json.Unmarshal(msg.Scores, &scores)
+			c.emit(ResultEvent{
+				GameID:     msg.GameID,
+				Round:      msg.Round,
+				Winner:     msg.Winner,
+				WinnerID:   msg.WinnerID,
+				WinnerName: msg.WinnerName,
+				Scores:     scores,
+			})
+		case protocol.TypeCursor:
+			c.emit(CursorEvent{PlayerID: msg.PlayerID, Name: msg.Name, Team: msg.Team, X: msg.X, Y: msg.Y})
+		case protocol.TypeSelection:
+			c.emit(SelectionEvent{
+				PlayerID: msg.PlayerID,
+				Name:     msg.Name,
+				Team:     msg.Team,
+				X:        msg.X,
+				Y:        msg.Y,
+				Width:    msg.Width,
+				Height:   msg.Height,
+			})
+		case protocol.TypeChat:
+			c.emit(ChatEvent{PlayerID: msg.PlayerID, Name: msg.Name, Text: msg.Text, Time: msg.Time})
+		case protocol.TypeChatHistory:
+			c.emit(ChatHistoryEvent{Messages: msg.Messages})
+		case protocol.TypeNotice:
+			c.emit(NoticeEvent{Text: msg.Text, Time: msg.Time})
+		case protocol.TypeError:
+			select {
+			case c.errs <- &ServerError{Message: msg.Message}:
+			default:
+			}
+		case "":
+			state, err := msg.decodeState()
+			if err != nil {
+				continue
+			}
+			c.deliver(state)
+		}
+	}
+}
+
+// emit queues an event, dropping it if the consumer has fallen behind.
+func (c *Client) emit(e Event) {
+	select {
+	case c.events <- e:
+	default:
+	}
+}
+
+func (m *wireMessage) decodeState() (*State, error) {
+	var board, owners [][]uint8
+	var err error
+	if m.Board != nil {
+		if board, err = protocol.DecodeRows(m.Board); err != nil {
+			return nil, err
+		}
+	}
+	if m.Owners != nil {
+		if owners, err = protocol.DecodeRows(m.Owners); err != nil {
+			return nil, err
+		}
+	}
+	var density [][]uint8
+	if m.Density != nil {
+		if density, err = protocol.DecodeRows(m.Density); err != nil {
+			return nil, err
+		}
+	}
+	var view *Viewport
+	if m.View != nil {
+		view = &Viewport{X: m.View.X, Y: m.View.Y, Width: m.View.Width, Height: m.View.Height, Zoom: m.View.Zoom}
+	}
+	return &State{
+		Board:           board,
+		Width:           m.Width,
+		Height:          m.Height,
+		CellSize:        m.CellSize,
+		Color:           m.Color,
+		BackgroundColor: m.BackgroundColor,
+		Interval:        m.Interval,
+		Stopped:         m.Stopped,
+		Mode:            m.Mode,
+		Rule:            m.Rule,
+		Owners:          owners,
+		TeamColors:      m.TeamColors,
+		View:            view,
+		Density:         density,
+	}, nil
+}
+
+// deliver queues state, dropping the oldest queued state if the consumer
+// has fallen behind.
+func (c *Client) deliver(state *State) {
+	for {
+		select {
+		case c.states <- state:
+			return
+		default:
+		}
+		select {
+		case <-c.states:
+		default:
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"GameOfLife/pkg/protocol"
+	"GameOfLife/pkg/server"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// newTestServer serves a real server until the test ends and returns its
+// host:port. The game loop does not run, so boards only change in response
+// to messages.
+func newTestServer(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(server.New(server.DefaultConfig()).Handler())
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+// fakeServer accepts WebSocket connections on /ws and hands each to the
+// test, which then plays the server's side of the protocol.
+func fakeServer(t *testing.T) (string, <-chan *websocket.Conn) {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://"), conns
+}
+
+func dial(t *testing.T, addr string) *Client {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// accept dials the fake server and returns the client with the server's
+// end of the connection.
+func accept(t *testing.T) (*Client, *websocket.Conn) {
+	t.Helper()
+	addr, conns := fakeServer(t)
+	c := dial(t, addr)
+	select {
+	case conn := <-conns:
+		t.Cleanup(func() { conn.Close() })
+		return c, conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake server did not receive a connection")
+		return nil, nil
+	}
+}
+
+// receive waits for the next value on ch.
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the client")
+	}
+	var zero T
+	return zero
+}
+
+// nextEvent waits for the next event of type T, skipping any others.
+func nextEvent[T Event](t *testing.T, c *Client) T {
+	t.Helper()
+	for {
+		if e, ok := receive(t, c.Events()).(T); ok {
+			return e
+		}
+	}
+}
+
+// readMessage reads the next message the client sent to the fake server.
+func readMessage(t *testing.T, conn *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg map[string]interface{}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("reading client message: %v", err)
+	}
+	return msg
+}
+
+func TestDialAndInit(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	if err := c.Init("g1", InitOptions{Width: 40, Height: 30, CellSize: 5, Fill: FillRandom}); err != nil {
+		t.Fatal(err)
+	}
+	state := receive(t, c.States())
+	if state.Width != 40 || state.Height != 30 || len(state.Board) != 30 {
+		t.Fatalf("state is %dx%d with %d rows, want 40x30", state.Width, state.Height, len(state.Board))
+	}
+	// A zero Density must leave the server's default, not ask for 0%.
+	if state.LiveCells() == 0 {
+		t.Error("random fill with the default density produced an empty board")
+	}
+	if c.GameID() != "g1" || c.Role() != RoleOwner || c.PlayerID() == "" {
+		t.Errorf("game %q, role %q, player %q; want g1, owner and a player ID", c.GameID(), c.Role(), c.PlayerID())
+	}
+	if owner, invite := c.Tokens(); owner == "" || invite == "" {
+		t.Error("the owner did not receive both tokens")
+	}
+}
+
+func TestInitMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		opts InitOptions
+		want map[string]interface{}
+	}{
+		{"defaults", InitOptions{Width: 20, Height: 10}, map[string]interface{}{}},
+		{"random without density", InitOptions{Fill: FillRandom}, map[string]interface{}{"fill": FillRandom}},
+		{"random with density", InitOptions{Fill: FillRandom, Density: 35}, map[string]interface{}{"fill": FillRandom, "density": 35.0}},
+		{"territory", InitOptions{Mode: ModeTerritory, Budget: 12}, map[string]interface{}{"mode": ModeTerritory, "budget": 12.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := accept(t)
+			if err := c.Init("g1", tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			msg := readMessage(t, conn)
+			for _, key := range []string{"type", "gameID", "width", "height", "cellSize"} {
+				delete(msg, key)
+			}
+			if !reflect.DeepEqual(msg, tt.want) {
+				t.Errorf("optional fields = %v, want %v", msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinWithToken(t *testing.T) {
+	addr := newTestServer(t)
+	owner := dial(t, addr)
+	owner.Init("g1", InitOptions{Width: 20, Height: 10, CellSize: 5, Fill: FillEmpty})
+	receive(t, owner.States())
+	_, invite := owner.Tokens()
+
+	editor := dial(t, addr)
+	editor.SetName("Ada")
+	if err := editor.Join("g1", invite); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, editor.States())
+	if editor.Role() != RoleEditor {
+		t.Errorf("role with the invite token = %q, want %q", editor.Role(), RoleEditor)
+	}
+	presence := nextEvent[PresenceEvent](t, editor)
+	if len(presence.Players) != 2 || presence.Players[1].Name != "Ada" {
+		t.Errorf("players = %+v, want the owner and Ada", presence.Players)
+	}
+
+	spectator := dial(t, addr)
+	spectator.Join("g1", "")
+	receive(t, spectator.States())
+	if spectator.Role() != RoleSpectator {
+		t.Errorf("role without a token = %q, want %q", spectator.Role(), RoleSpectator)
+	}
+}
+
+func TestBirth(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	if err := c.Birth(3, 3); !errors.Is(err, ErrNoGame) {
+		t.Fatalf("Birth before joining a game = %v, want ErrNoGame", err)
+	}
+	c.Init("g1", InitOptions{Width: 20, Height: 10, CellSize: 5, Fill: FillEmpty})
+	receive(t, c.States())
+
+	if err := c.Birth(3, 4); err != nil {
+		t.Fatal(err)
+	}
+	state := receive(t, c.States())
+	if !state.Alive(3, 4) || state.LiveCells() != 1 {
+		t.Error("the born cell is not the only live cell")
+	}
+	action := nextEvent[ActionEvent](t, c)
+	if action.Action != protocol.TypeBirth || action.X != 3 || action.Y != 4 || action.PlayerID != c.PlayerID() {
+		t.Errorf("action = %+v, want our birth at (3, 4)", action)
+	}
+}
+
+func TestStates(t *testing.T) {
+	c, conn := accept(t)
+	board := [][]uint8{{0, 1, 0}, {1, protocol.Alive, 1}, {0, 1, 0}}
+	conn.WriteJSON(protocol.BoardState{
+		Board:  protocol.EncodeRows(board),
+		Width:  3,
+		Height: 3,
+		Color:  "#123456",
+		Rule:   "B3/S23",
+	})
+	state := receive(t, c.States())
+	if !reflect.DeepEqual(state.Board, board) || state.Color != "#123456" || state.Rule != "B3/S23" {
+		t.Errorf("state = %+v, want the board, colour and rule sent", state)
+	}
+	if !state.Alive(1, 1) || state.Alive(0, 1) || state.View != nil || state.Density != nil {
+		t.Error("whole-board state decoded wrongly")
+	}
+
+	density := [][]uint8{{0, 255}, {64, 128}}
+	owners := [][]uint8{{0, 2}, {1, 1}}
+	conn.WriteJSON(protocol.BoardState{
+		Width:   100,
+		Height:  100,
+		Owners:  protocol.EncodeRows(owners),
+		View:    &protocol.Viewport{X: 8, Y: 16, Width: 8, Height: 8, Zoom: 4},
+		Density: protocol.EncodeRows(density),
+	})
+	state = receive(t, c.States())
+	if state.Board != nil || !reflect.DeepEqual(state.Density, density) {
+		t.Errorf("zoomed state has board %v and density %v, want no board and %v", state.Board, state.Density, density)
+	}
+	if want := (Viewport{X: 8, Y: 16, Width: 8, Height: 8, Zoom: 4}); state.View == nil || *state.View != want {
+		t.Errorf("view = %+v, want %+v", state.View, want)
+	}
+	// (13, 17) lies in the second block of the first row of blocks.
+	if got := state.Owner(13, 17); got != 2 {
+		t.Errorf("Owner(13, 17) = %d, want 2", got)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	c, conn := accept(t)
+	messages := []string{
+		`{"type":"action","action":"setBackgroundColor","playerID":"p1","name":"Ada","color":"#ff0000"}`,
+		`{"type":"presence","gameID":"g1","players":[{"id":"p1","name":"Ada","role":"owner"}]}`,
+		`{"type":"chat","playerID":"p1","name":"Ada","text":"hi","time":"2024-01-02T03:04:05Z"}`,
+		`{"type":"notice","text":"restarting soon","time":"2024-01-02T03:04:05Z"}`,
+		`{"type":"error","message":"game g2 not found"}`,
+		// The board state's Color must not be confused with an action's color.
+		`{"Width":3,"Height":3,"Color":"#00ff00","Board":["AAAA","AAAA","AAAA"]}`,
+	}
+	for _, m := range messages {
+		conn.WriteMessage(websocket.TextMessage, []byte(m))
+	}
+
+	if e := receive(t, c.Events()).(ActionEvent); e.Action != protocol.TypeSetBackgroundColor || e.Color != "#ff0000" || e.Name != "Ada" {
+		t.Errorf("action = %+v, want Ada's background colour #ff0000", e)
+	}
+	want := []Player{{ID: "p1", Name: "Ada", Role: RoleOwner}}
+	if e := receive(t, c.Events()).(PresenceEvent); e.GameID != "g1" || !reflect.DeepEqual(e.Players, want) {
+		t.Errorf("presence = %+v, want %+v", e, want)
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if e := receive(t, c.Events()).(ChatEvent); e.Text != "hi" || e.Name != "Ada" || !e.Time.Equal(at) {
+		t.Errorf("chat = %+v, want Ada's hi at %v", e, at)
+	}
+	if e := receive(t, c.Events()).(NoticeEvent); e.Text != "restarting soon" {
+		t.Errorf("notice = %+v", e)
+	}
+	var serverErr *ServerError
+	if err := receive(t, c.Errors()); !errors.As(err, &serverErr) || serverErr.Message != "game g2 not found" {
+		t.Errorf("error = %v, want the server's message", err)
+	}
+	if state := receive(t, c.States()); state.Color != "#00ff00" {
+		t.Errorf("state colour = %q, want #00ff00", state.Color)
+	}
+
+	conn.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done was not closed after the server hung up")
+	}
+	if c.Err() == nil {
+		t.Error("Err() = nil after the connection ended")
+	}
+}
@@ -0,0 +1,160 @@
+// Package engine implements the Game of Life board: the cell encoding,
+// Life-like rules, generations and the team ownership used by the
+// competitive modes. It has no knowledge of players or networking.
+//
+//	g := engine.New(40, 30)
+//	g.Birth(10, 10)
+//	g.Birth(11, 10)
+//	g.Birth(12, 10)
+//	g.Update() // the blinker turns vertical
+//
+// A GameState is not safe for concurrent use; the server guards each game
+// with its own mutex.
+package engine
+
+// Cells are stored as one byte each. Values of Alive and above are live
+// cells, and the remainder is the number of live neighbours, so a
+// generation needs no second pass to count them.
+const Alive = 100
+
+// GameState is a board with a dead border one cell wide around the playable
+// area, so the neighbours of every playable cell are in range.
+type GameState struct {
+	Board  [][]uint8
+	Width  int
+	Height int
+	Rule   Rule
+	Owners [][]uint8 // team of each live cell, nil unless teams are enabled
+	Teams  int       // number of teams, 0 in classic games
+}
+
+// New returns an empty width x height board playing B3/S23.
+func New(width, height int) *GameState {
+	board := make([][]uint8, height)
+	for i := range board {
+		board[i] = make([]uint8, width)
+	}
+	return &GameState{
+		Board:  board,
+		Width:  width,
+		Height: height,
+		Rule:   Conway,
+	}
+}
+
+// Birth marks a dead cell in the playable area as alive and updates its
+// neighbours' counts. It reports whether a cell was born, which it is not
+// outside the playable area or where a cell is already alive.
+func (g *GameState) Birth(x, y int) bool {
+	if x <= 0 || x >= g.Width-1 || y <= 0 || y >= g.Height-1 || g.Board[y][x] >= Alive {
+		return false
+	}
+	g.Board[y][x] += Alive
+	g.Board[y-1][x]++
+	g.Board[y+1][x]++
+	g.Board[y-1][x-1]++
+	g.Board[y-1][x+1]++
+	g.Board[y][x-1]++
+	g.Board[y][x+1]++
+	g.Board[y+1][x-1]++
+	g.Board[y+1][x+1]++
+	return true
+}
+
+// Alive reports whether the cell at (x, y) is alive. Cells off the board
+// are dead.
+func (g *GameState) Alive(x, y int) bool {
+	return y >= 0 && y < len(g.Board) && x >= 0 && x < len(g.Board[y]) && g.Board[y][x] >= Alive
+}
+
+// Update advances the board by one generation under g.Rule and returns the
+// number of live cells.
+func (g *GameState) Update() int {
+	newBoard := make([][]uint8, g.Height)
+	for i := range newBoard {
+		newBoard[i] = make([]uint8, g.Width)
+	}
+	var newOwners [][]uint8
+	if g.Owners != nil {
+		newOwners = make([][]uint8, g.Height)
+		for i := range newOwners {
+			newOwners[i] = make([]uint8, g.Width)
+		}
+	}
+
+	liveCells := 0
+	for y := 1; y < g.Height-1; y++ {
+		for x := 1; x < g.Width-1; x++ {
+			neighbors := g.Board[y][x]
+			if neighbors >= Alive {
+				neighbors -= Alive
+			}
+			isAlive := g.Board[y][x] >= Alive
+			if (isAlive && g.Rule.survive[neighbors]) || (!isAlive && g.Rule.birth[neighbors]) {
+				newBoard[y][x] += Alive
+				newBoard[y-1][x]++
+				newBoard[y+1][x]++
+				newBoard[y-1][x-1]++
+				newBoard[y-1][x+1]++
+				newBoard[y][x-1]++
+				newBoard[y][x+1]++
+				newBoard[y+1][x-1]++
+				newBoard[y+1][x+1]++
+				liveCells++
+				if newOwners != nil {
+					if isAlive {
+						newOwners[y][x] = g.Owners[y][x]
+					} else {
+						newOwners[y][x] = g.birthOwner(x, y)
+					}
+				}
+			}
+		}
+	}
+	g.Board = newBoard
+	g.Owners = newOwners
+	return liveCells
+}
+
+// LiveCells counts the live cells on the board.
+func (g *GameState) LiveCells() int {
+	n := 0
+	for _, row := range g.Board {
+		for _, cell := range row {
+			if cell >= Alive {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Clear kills every cell and forgets every owner.
+func (g *GameState) Clear() {
+	for _, row := range g.Board {
+		clear(row)
+	}
+	for _, row := range g.Owners {
+		clear(row)
+	}
+}
+
+// Clone returns a deep copy of the board that can be advanced or rendered
+// independently.
+func (g *GameState) Clone() *GameState {
+	c := *g
+	c.Board = cloneRows(g.Board)
+	c.Owners = cloneRows(g.Owners)
+	return &c
+}
+
+func cloneRows(rows [][]uint8) [][]uint8 {
+	if rows == nil {
+		return nil
+	}
+	c := make([][]uint8, len(rows))
+	for i, row := range rows {
+		c[i] = append([]uint8(nil), row...)
+	}
+	return c
+}
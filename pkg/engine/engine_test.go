@@ -0,0 +1,358 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+// place births the cells marked 'O' in rows with their top left corner at
+// (x, y).
+func place(g *GameState, x, y int, rows ...string) {
+	for dy, row := range rows {
+		for dx, c := range row {
+			if c == 'O' {
+				g.Birth(x+dx, y+dy)
+			}
+		}
+	}
+}
+
+// render draws the w x h area of g with its top left corner at (x, y),
+// one row per line, in the notation place reads.
+func render(g *GameState, x, y, w, h int) string {
+	var b strings.Builder
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			if g.Alive(x+dx, y+dy) {
+				b.WriteByte('O')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// checkCounts verifies the neighbour count stored in every cell against a
+// recount of its live neighbours.
+func checkCounts(t *testing.T, g *GameState) {
+	t.Helper()
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			want := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if (dx != 0 || dy != 0) && g.Alive(x+dx, y+dy) {
+						want++
+					}
+				}
+			}
+			if got := int(g.Board[y][x] % Alive); got != want {
+				t.Fatalf("cell (%d, %d) counts %d neighbours, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestUpdateKnownPatterns(t *testing.T) {
+	tests := []struct {
+		name        string
+		start       []string
+		generations int
+		want        []string // the same area after generations
+	}{
+		{
+			name:        "block stays",
+			start:       []string{"....", ".OO.", ".OO.", "...."},
+			generations: 10,
+			want:        []string{"....", ".OO.", ".OO.", "...."},
+		},
+		{
+			name:        "beehive stays",
+			start:       []string{"......", "..OO..", ".O..O.", "..OO..", "......"},
+			generations: 10,
+			want:        []string{"......", "..OO..", ".O..O.", "..OO..", "......"},
+		},
+		{
+			name:        "blinker turns",
+			start:       []string{".....", ".....", ".OOO.", ".....", "....."},
+			generations: 1,
+			want:        []string{".....", "..O..", "..O..", "..O..", "....."},
+		},
+		{
+			name:        "blinker has period 2",
+			start:       []string{".....", ".....", ".OOO.", ".....", "....."},
+			generations: 2,
+			want:        []string{".....", ".....", ".OOO.", ".....", "....."},
+		},
+		{
+			name:        "toad has period 2",
+			start:       []string{"......", "......", "..OOO.", ".OOO..", "......", "......"},
+			generations: 2,
+			want:        []string{"......", "......", "..OOO.", ".OOO..", "......", "......"},
+		},
+		{
+			name:        "glider moves one cell diagonally every 4 generations",
+			start:       []string{".O....", "..O...", "OOO...", "......", "......", "......"},
+			generations: 4,
+			want:        []string{"......", "..O...", "...O..", ".OOO..", "......", "......"},
+		},
+		{
+			name:        "glider after 8 generations",
+			start:       []string{".O....", "..O...", "OOO...", "......", "......", "......"},
+			generations: 8,
+			want:        []string{"......", "......", "...O..", "....O.", "..OOO.", "......"},
+		},
+		{
+			name:        "lone cell dies",
+			start:       []string{"...", ".O.", "..."},
+			generations: 1,
+			want:        []string{"...", "...", "..."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(20, 20)
+			place(g, 5, 5, tt.start...)
+			for i := 0; i < tt.generations; i++ {
+				g.Update()
+				checkCounts(t, g)
+			}
+			want := strings.Join(tt.want, "\n") + "\n"
+			if got := render(g, 5, 5, len(tt.want[0]), len(tt.want)); got != want {
+				t.Errorf("after %d generations got\n%swant\n%s", tt.generations, got, want)
+			}
+		})
+	}
+}
+
+func TestUpdateReturnsLiveCells(t *testing.T) {
+	g := New(10, 10)
+	place(g, 3, 3, "OOO")
+	if got := g.Update(); got != 3 {
+		t.Errorf("Update() = %d, want 3", got)
+	}
+	if got := g.LiveCells(); got != 3 {
+		t.Errorf("LiveCells() = %d, want 3", got)
+	}
+}
+
+func TestUpdateKeepsBorderDead(t *testing.T) {
+	g := New(6, 6)
+	// Cells in the corner of the playable area would spread into the
+	// border if the border were playable.
+	place(g, 1, 1, "OOO", "OO")
+	for i := 0; i < 5; i++ {
+		g.Update()
+		for x := 0; x < g.Width; x++ {
+			if g.Alive(x, 0) || g.Alive(x, g.Height-1) {
+				t.Fatalf("generation %d: cell alive in the top or bottom border", i+1)
+			}
+		}
+		for y := 0; y < g.Height; y++ {
+			if g.Alive(0, y) || g.Alive(g.Width-1, y) {
+				t.Fatalf("generation %d: cell alive in the left or right border", i+1)
+			}
+		}
+	}
+}
+
+func TestRPentominoStabilizes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs 1103 generations on a large board")
+	}
+	// The R-pentomino settles at generation 1103 with 116 cells, six of
+	// them escaping gliders. The board leaves the gliders room to travel
+	// without reaching the border first.
+	const size = 700
+	g := New(size, size)
+	place(g, size/2, size/2, ".OO", "OO.", ".O.")
+	populations := make([]int, 1110)
+	for i := 1; i < len(populations); i++ {
+		populations[i] = g.Update()
+	}
+	if populations[1102] == 116 {
+		t.Errorf("population at generation 1102 is already 116, want the pattern still evolving")
+	}
+	for gen := 1103; gen < len(populations); gen++ {
+		if populations[gen] != 116 {
+			t.Errorf("population at generation %d = %d, want 116", gen, populations[gen])
+		}
+	}
+	checkCounts(t, g)
+}
+
+func TestBirth(t *testing.T) {
+	g := New(5, 5)
+	if !g.Birth(2, 2) {
+		t.Fatal("Birth(2, 2) on an empty board = false, want true")
+	}
+	if g.Birth(2, 2) {
+		t.Error("Birth(2, 2) on a live cell = true, want false")
+	}
+	for _, p := range [][2]int{{0, 2}, {4, 2}, {2, 0}, {2, 4}, {-1, 2}, {2, 9}} {
+		if g.Birth(p[0], p[1]) {
+			t.Errorf("Birth(%d, %d) outside the playable area = true, want false", p[0], p[1])
+		}
+	}
+	// A birth next to a live cell must keep the count of its neighbour.
+	g.Birth(3, 2)
+	checkCounts(t, g)
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "B3/S23", want: "B3/S23"},
+		{in: "23/3", want: "B3/S23"},
+		{in: "b36/s23", want: "B36/S23"},
+		{in: "B2/S", want: "B2/S"},
+		{in: "B9/S23", wantErr: true},
+		{in: "B3", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRule(%q) = %v, want an error", tt.in, rule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRule(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("ParseRule(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateFollowsRule(t *testing.T) {
+	// The centre cell is dead with six live neighbours: B36/S23 gives it
+	// birth and B3/S23 does not.
+	start := []string{"OOO", "O.O", "O.."}
+	for _, tt := range []struct {
+		rule string
+		want bool
+	}{
+		{rule: "B3/S23", want: false},
+		{rule: "B36/S23", want: true},
+	} {
+		rule, err := ParseRule(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := New(10, 10)
+		g.Rule = rule
+		place(g, 3, 3, start...)
+		g.Update()
+		if got := g.Alive(4, 4); got != tt.want {
+			t.Errorf("%s: centre cell alive = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	// Each case resizes a 7x7 board with a block at (2,2)-(3,3). The
+	// expected renders cover the whole new board, border included.
+	tests := []struct {
+		name          string
+		width, height int
+		anchor        string
+		want          string
+	}{
+		{"grow from center", 9, 9, AnchorCenter, "" +
+			".........\n" +
+			".........\n" +
+			".........\n" +
+			"...OO....\n" +
+			"...OO....\n" +
+			".........\n" +
+			".........\n" +
+			".........\n" +
+			".........\n"},
+		{"grow from top-left", 9, 8, AnchorTopLeft, "" +
+			".........\n" +
+			".........\n" +
+			"..OO.....\n" +
+			"..OO.....\n" +
+			".........\n" +
+			".........\n" +
+			".........\n" +
+			".........\n"},
+		{"grow from bottom-right", 9, 8, AnchorBottomRight, "" +
+			".........\n" +
+			".........\n" +
+			".........\n" +
+			"....OO...\n" +
+			"....OO...\n" +
+			".........\n" +
+			".........\n" +
+			".........\n"},
+		{"shrink from top-left", 5, 5, AnchorTopLeft, "" +
+			".....\n" +
+			".....\n" +
+			"..OO.\n" +
+			"..OO.\n" +
+			".....\n"},
+		{"shrink from bottom-right clips the block", 5, 5, AnchorBottomRight, "" +
+			".....\n" +
+			".O...\n" +
+			".....\n" +
+			".....\n" +
+			".....\n"},
+		{"shrink from bottom-right drops the block", 4, 4, AnchorBottomRight, "" +
+			"....\n" +
+			"....\n" +
+			"....\n" +
+			"....\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(7, 7)
+			place(g, 2, 2, "OO", "OO")
+			if err := g.Resize(tt.width, tt.height, tt.anchor); err != nil {
+				t.Fatal(err)
+			}
+			if g.Width != tt.width || g.Height != tt.height {
+				t.Fatalf("size = %dx%d, want %dx%d", g.Width, g.Height, tt.width, tt.height)
+			}
+			if got := render(g, 0, 0, g.Width, g.Height); got != tt.want {
+				t.Errorf("board after resize:\n%swant:\n%s", got, tt.want)
+			}
+			checkCounts(t, g)
+		})
+	}
+}
+
+func TestResizeKeepsOwners(t *testing.T) {
+	g := New(6, 6)
+	g.EnableTeams(2)
+	g.Birth(2, 2)
+	g.Owners[2][2] = 2
+	if err := g.Resize(8, 8, AnchorBottomRight); err != nil {
+		t.Fatal(err)
+	}
+	if !g.Alive(4, 4) || g.Owners[4][4] != 2 {
+		t.Errorf("cell at (4, 4): alive %v, owner %d, want alive and owned by team 2", g.Alive(4, 4), g.Owners[4][4])
+	}
+}
+
+func TestResizeRejectsBadArguments(t *testing.T) {
+	g := New(6, 6)
+	if err := g.Resize(8, 8, "middle"); err == nil {
+		t.Error("Resize with an unknown anchor succeeded")
+	}
+	if err := g.Resize(2, 8, AnchorCenter); err == nil {
+		t.Error("Resize below the minimum size succeeded")
+	}
+	if g.Width != 6 || g.Height != 6 {
+		t.Errorf("failed resizes changed the size to %dx%d", g.Width, g.Height)
+	}
+}
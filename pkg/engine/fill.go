@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Symmetry groups accepted by SymmetricSoup.
+const (
+	C2 = "c2" // 180° rotation
+	C4 = "c4" // 90° rotations
+	D8 = "d8" // rotations and reflections
+)
+
+// Randomize brings each dead cell of the playable area to life with the
+// given percent probability.
+func (g *GameState) Randomize(percent int) {
+	for y := 1; y < g.Height-1; y++ {
+		for x := 1; x < g.Width-1; x++ {
+			if g.Board[y][x] < Alive && rand.Intn(100) < percent {
+				g.Birth(x, y)
+			}
+		}
+	}
+}
+
+// SymmetricSoup fills the board with a random soup of the given density
+// that is invariant under the symmetry group. C2 covers the whole playable
+// area, while C4 and D8 need a square and use the largest one centred on the
+// board.
+func (g *GameState) SymmetricSoup(symmetry string, density int) {
+	w, h := g.Width-2, g.Height-2
+	if w <= 0 || h <= 0 {
+		return
+	}
+	x0, y0 := 1, 1
+	if symmetry != C2 {
+		n := min(w, h)
+		x0 += (w - n) / 2
+		y0 += (h - n) / 2
+		w, h = n, n
+	}
+
+	// Each orbit of the group takes the decision made for its smallest member.
+	alive := make([]bool, w*h)
+	for i := range alive {
+		alive[i] = rand.Intn(100) < density
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			canon := y*w + x
+			for _, p := range orbit(symmetry, x, y, w, h) {
+				canon = min(canon, p[1]*w+p[0])
+			}
+			if alive[canon] {
+				g.Birth(x0+x, y0+y)
+			}
+		}
+	}
+}
+
+// orbit returns the images of (x, y) under the symmetry group within a
+// w x h region.
+func orbit(symmetry string, x, y, w, h int) [][2]int {
+	images := [][2]int{{x, y}, {w - 1 - x, h - 1 - y}}
+	if symmetry == C2 {
+		return images
+	}
+	n := w
+	images = append(images, [2]int{n - 1 - y, x}, [2]int{y, n - 1 - x})
+	if symmetry == C4 {
+		return images
+	}
+	return append(images,
+		[2]int{n - 1 - x, y},
+		[2]int{x, n - 1 - y},
+		[2]int{y, x},
+		[2]int{n - 1 - y, n - 1 - x},
+	)
+}
+
+// Stamp places cells centred on the board, failing if they do not fit
+// inside the playable area.
+func (g *GameState) Stamp(cells [][]bool) error {
+	ph := len(cells)
+	pw := 0
+	for _, row := range cells {
+		pw = max(pw, len(row))
+	}
+	if pw > g.Width-2 || ph > g.Height-2 {
+		return fmt.Errorf("pattern of %dx%d does not fit a %dx%d board", pw, ph, g.Width, g.Height)
+	}
+	xOffset := (g.Width - pw) / 2
+	yOffset := (g.Height - ph) / 2
+	for y, row := range cells {
+		for x, live := range row {
+			if live {
+				g.Birth(xOffset+x, yOffset+y)
+			}
+		}
+	}
+	return nil
+}
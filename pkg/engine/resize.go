@@ -0,0 +1,69 @@
+package engine
+
+import "fmt"
+
+// MinSize is the smallest board width and height: one playable cell inside
+// the dead border.
+const MinSize = 3
+
+// Anchors accepted by Resize. The anchor is the point of the playable area
+// that keeps its place on the board while the edges opposite it move.
+const (
+	AnchorCenter      = "center"
+	AnchorTopLeft     = "top-left"
+	AnchorTopRight    = "top-right"
+	AnchorBottomLeft  = "bottom-left"
+	AnchorBottomRight = "bottom-right"
+)
+
+// Anchors lists the accepted anchors.
+var Anchors = []string{AnchorCenter, AnchorTopLeft, AnchorTopRight, AnchorBottomLeft, AnchorBottomRight}
+
+// ValidAnchor reports whether anchor is accepted by Resize.
+func ValidAnchor(anchor string) bool {
+	_, ok := anchorWeights[anchor]
+	return ok
+}
+
+// anchorWeights gives, per anchor, the share of a change in width and in
+// height added on the left and top, in halves.
+var anchorWeights = map[string][2]int{
+	AnchorCenter:      {1, 1},
+	AnchorTopLeft:     {0, 0},
+	AnchorTopRight:    {2, 0},
+	AnchorBottomLeft:  {0, 2},
+	AnchorBottomRight: {2, 2},
+}
+
+// Resize changes the board to width x height. Live cells keep their owners
+// and their place relative to anchor; those that fall outside the new
+// playable area are dropped.
+func (g *GameState) Resize(width, height int, anchor string) error {
+	weights, ok := anchorWeights[anchor]
+	if !ok {
+		return fmt.Errorf("unknown anchor %q", anchor)
+	}
+	if width < MinSize || height < MinSize {
+		return fmt.Errorf("board %dx%d is smaller than the %dx%d minimum", width, height, MinSize, MinSize)
+	}
+	dx := (width - g.Width) * weights[0] / 2
+	dy := (height - g.Height) * weights[1] / 2
+
+	resized := New(width, height)
+	if g.Owners != nil {
+		resized.EnableTeams(g.Teams)
+	}
+	for y := 1; y < g.Height-1; y++ {
+		for x := 1; x < g.Width-1; x++ {
+			if g.Board[y][x] < Alive || !resized.Birth(x+dx, y+dy) {
+				continue
+			}
+			if g.Owners != nil {
+				resized.Owners[y+dy][x+dx] = g.Owners[y][x]
+			}
+		}
+	}
+	g.Board, g.Owners = resized.Board, resized.Owners
+	g.Width, g.Height = width, height
+	return nil
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule is a Life-like rule: a dead cell with a neighbour count marked in
+// birth comes alive, and a live cell with a count marked in survive stays
+// alive.
+type Rule struct {
+	birth, survive [9]bool
+}
+
+// Conway is B3/S23, the rule of Conway's Game of Life and of every new board.
+var Conway = Rule{
+	birth:   [9]bool{3: true},
+	survive: [9]bool{2: true, 3: true},
+}
+
+// ParseRule reads a rule in B/S notation such as "B36/S23". The older S/B
+// notation, "23/36", is accepted too.
+func ParseRule(s string) (Rule, error) {
+	var r Rule
+	first, second, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(s)), "/")
+	if !ok {
+		return r, fmt.Errorf("rule %q is not in B/S notation, e.g. B3/S23", s)
+	}
+	birth, survive := first, second
+	if strings.HasPrefix(second, "B") || strings.HasPrefix(first, "S") {
+		birth, survive = second, first
+	} else if !strings.HasPrefix(first, "B") {
+		// S/B notation: survival counts come first.
+		birth, survive = "B"+second, "S"+first
+	}
+	if !strings.HasPrefix(birth, "B") || !strings.HasPrefix(survive, "S") {
+		return r, fmt.Errorf("rule %q is not in B/S notation, e.g. B3/S23", s)
+	}
+	for i, counts := range []string{birth[1:], survive[1:]} {
+		for _, c := range counts {
+			if c < '0' || c > '8' {
+				return r, fmt.Errorf("rule %q has neighbour count %q, want 0 to 8", s, c)
+			}
+			if i == 0 {
+				r.birth[c-'0'] = true
+			} else {
+				r.survive[c-'0'] = true
+			}
+		}
+	}
+	return r, nil
+}
+
+// String returns the rule in B/S notation.
+func (r Rule) String() string {
+	var b strings.Builder
+	b.WriteByte('B')
+	for n, on := range r.birth {
+		if on {
+			b.WriteByte(byte('0' + n))
+		}
+	}
+	b.WriteString("/S")
+	for n, on := range r.survive {
+		if on {
+			b.WriteByte(byte('0' + n))
+		}
+	}
+	return b.String()
+}
+
+// MarshalText and UnmarshalText store the rule in B/S notation, so saved
+// games stay readable.
+func (r Rule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rule) UnmarshalText(text []byte) error {
+	parsed, err := ParseRule(string(text))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
@@ -0,0 +1,80 @@
+package engine
+
+import "math/rand"
+
+// EnableTeams gives every live cell an owner among the given number of
+// teams. Cells already on the board are shared out between the teams at
+// random. Newborn cells take the team most of their parents share.
+func (g *GameState) EnableTeams(teams int) {
+	g.Teams = teams
+	g.Owners = make([][]uint8, g.Height)
+	for y := range g.Owners {
+		g.Owners[y] = make([]uint8, g.Width)
+		for x := range g.Owners[y] {
+			if g.Board[y][x] >= Alive {
+				g.Owners[y][x] = uint8(rand.Intn(teams) + 1)
+			}
+		}
+	}
+}
+
+// birthOwner picks the team of a cell born at (x, y) from its three live
+// neighbours: the majority team, or with four teams, when all three differ,
+// the one team that is missing.
+func (g *GameState) birthOwner(x, y int) uint8 {
+	var count [256]int
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx != 0 || dy != 0) && g.Board[y+dy][x+dx] >= Alive {
+				count[g.Owners[y+dy][x+dx]]++
+			}
+		}
+	}
+	for team := 1; team <= g.Teams; team++ {
+		if count[team] >= 2 {
+			return uint8(team)
+		}
+	}
+	for team := 1; team <= g.Teams; team++ {
+		if count[team] == 0 {
+			return uint8(team)
+		}
+	}
+	return 1
+}
+
+// Owner returns the team owning the cell at (x, y), or 0 if there is none.
+func (g *GameState) Owner(x, y int) int {
+	if y < 0 || y >= len(g.Owners) || x < 0 || x >= len(g.Owners[y]) {
+		return 0
+	}
+	return int(g.Owners[y][x])
+}
+
+// ClaimUnowned gives every live cell without an owner to team, which is how
+// cells placed by a player get that player's colour.
+func (g *GameState) ClaimUnowned(team int) {
+	if g.Owners == nil || team == 0 {
+		return
+	}
+	for y := range g.Board {
+		for x, cell := range g.Board[y] {
+			if cell >= Alive && g.Owners[y][x] == 0 {
+				g.Owners[y][x] = uint8(team)
+			}
+		}
+	}
+}
+
+// CountTeams counts live cells per team, indexed by team - 1.
+func (g *GameState) CountTeams() []int {
+	counts := make([]int, g.Teams)
+	for y, row := range g.Board {
+		for x, cell := range row {
+			if cell >= Alive && g.Owners != nil && g.Owners[y][x] > 0 && int(g.Owners[y][x]) <= g.Teams {
+				counts[g.Owners[y][x]-1]++
+			}
+		}
+	}
+	return counts
+}
@@ -0,0 +1,4 @@
+// Package patterns holds the built-in pattern library and reads and writes
+// patterns in the run-length encoded (RLE) format used by most Life
+// software.
+package patterns
@@ -0,0 +1,191 @@
+package patterns
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+	"sort"
+)
+
+// Pattern is a pattern of the built-in library.
+type Pattern struct {
+	Name  string
+	Cells [][]bool // rows of cells, true where alive
+	// Scale, when set, enlarges the pattern to about this fraction of the
+	// board width, each cell becoming a square block, and centres it.
+	// Patterns without one are placed at a random position at their
+	// natural size.
+	Scale float64
+}
+
+// library holds the patterns understood by the "pattern" message and the
+// "pattern" initial fill, by name.
+var library = map[string]*Pattern{}
+
+func init() {
+	for _, p := range []*Pattern{
+		{Name: "glider", Cells: cells(
+			".O.",
+			"..O",
+			"OOO",
+		)},
+		{Name: "blinker", Cells: cells(
+			"OOO",
+		)},
+		{Name: "toad", Cells: cells(
+			".OOO",
+			"OOO.",
+		)},
+		{Name: "pulsar", Cells: cells(
+			"..OOO...OOO..",
+			".............",
+			"O....O.O....O",
+			"O....O.O....O",
+			"O....O.O....O",
+			"..OOO...OOO..",
+			".............",
+			"..OOO...OOO..",
+			"O....O.O....O",
+			"O....O.O....O",
+			"O....O.O....O",
+			".............",
+			"..OOO...OOO..",
+		)},
+		{Name: "gosper_glider_gun", Scale: 0.5, Cells: cells(
+			"........................O...........",
+			"......................O.O...........",
+			"............OO......OO............OO",
+			"...........O...O....OO............OO",
+			"OO........O.....O...OO..............",
+			"OO........O...O.OO....O.O...........",
+			"..........O.....O.......O...........",
+			"...........O........................",
+			"............OO......................",
+		)},
+		{Name: "r_pentomino", Scale: 0.5, Cells: cells(
+			".OO",
+			"OO.",
+			".O.",
+		)},
+		// The snark's 34x34 bounding box is reserved, but its cells have
+		// not been filled in, so it places nothing.
+		{Name: "snark", Scale: 0.5, Cells: blank(34, 34)},
+		{Name: "2_engine", Scale: 0.5, Cells: cells(
+			"...................",
+			"...................",
+			"...................",
+			"...................",
+			"................O..",
+			"...............OO..",
+			"..............O.O..",
+			".............O..O..",
+			"............O......",
+			"...........O.......",
+			"..........O........",
+			".........O........",
+			"........O..........",
+			".......O...........",
+			"......O............",
+			".....O.............",
+			"...................",
+			"....OO.............",
+			"...................",
+		)},
+		// The Hilbert curve is approximated by a 64x64 checkerboard.
+		{Name: "david_hilbert", Scale: 0.75, Cells: checkerboard(64)},
+	} {
+		library[p.Name] = p
+	}
+}
+
+// Lookup returns the library pattern with the given name.
+func Lookup(name string) (*Pattern, bool) {
+	p, ok := library[name]
+	return p, ok
+}
+
+// Names lists the library patterns in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(library))
+	for name := range library {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Size returns the width and height of the pattern's bounding box.
+func (p *Pattern) Size() (width, height int) {
+	for _, row := range p.Cells {
+		width = max(width, len(row))
+	}
+	return width, len(p.Cells)
+}
+
+// Place returns the board coordinates of the pattern's live cells on a
+// width x height board with a dead border, failing if it does not fit
+// inside the playable area.
+func (p *Pattern) Place(width, height int) ([]image.Point, error) {
+	pw, ph := p.Size()
+	scale := 1
+	if p.Scale > 0 {
+		scale = max(int(float64(width)/float64(pw)*p.Scale), 1)
+	}
+	if pw*scale > width-2 || ph*scale > height-2 {
+		return nil, fmt.Errorf("pattern %q does not fit a %dx%d board", p.Name, width, height)
+	}
+	var x0, y0 int
+	if p.Scale > 0 {
+		x0, y0 = (width-pw*scale)/2, (height-ph*scale)/2
+	} else {
+		x0, y0 = rand.Intn(width-1-pw)+1, rand.Intn(height-1-ph)+1
+	}
+
+	var points []image.Point
+	for y, row := range p.Cells {
+		for x, live := range row {
+			if !live {
+				continue
+			}
+			for sy := 0; sy < scale; sy++ {
+				for sx := 0; sx < scale; sx++ {
+					points = append(points, image.Pt(x0+x*scale+sx, y0+y*scale+sy))
+				}
+			}
+		}
+	}
+	return points, nil
+}
+
+// cells turns rows in plaintext notation, "O" for a live cell and "." for
+// a dead one, into a cell grid.
+func cells(rows ...string) [][]bool {
+	grid := make([][]bool, len(rows))
+	for y, row := range rows {
+		grid[y] = make([]bool, len(row))
+		for x, c := range row {
+			grid[y][x] = c == 'O'
+		}
+	}
+	return grid
+}
+
+// blank returns a w x h grid of dead cells.
+func blank(w, h int) [][]bool {
+	grid := make([][]bool, h)
+	for y := range grid {
+		grid[y] = make([]bool, w)
+	}
+	return grid
+}
+
+// checkerboard returns an n x n grid with every other cell alive.
+func checkerboard(n int) [][]bool {
+	grid := blank(n, n)
+	for y := range grid {
+		for x := range grid[y] {
+			grid[y][x] = (x+y)%2 == 0
+		}
+	}
+	return grid
+}
@@ -0,0 +1,184 @@
+package patterns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxRLELength bounds the size of uploaded RLE strings.
+const MaxRLELength = 64 * 1024
+
+// maxRLECells bounds the area a decoded pattern may span, so a short string
+// of long runs cannot allocate a huge grid. It is the largest board the
+// server allows by default.
+const maxRLECells = 2000 * 2000
+
+// ParseRLE decodes a pattern in the run-length encoded format used by most
+// Life software. Comment lines starting with '#' are skipped, the optional
+// "x = .., y = .., rule = .." header is checked against the decoded cells,
+// and only the standard B3/S23 rule is accepted.
+func ParseRLE(s string) ([][]bool, error) {
+	declaredW, declaredH := -1, -1
+	var body strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "x") && body.Len() == 0 && declaredW < 0:
+			w, h, err := parseRLEHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			declaredW, declaredH = w, h
+		default:
+			body.WriteString(line)
+		}
+	}
+
+	var cells [][]bool
+	row := []bool{}
+	count := 0
+	area := 0 // cells in the rows before row
+	done := false
+	for _, c := range body.String() {
+		if done {
+			break
+		}
+		switch {
+		case c >= '0' && c <= '9':
+			count = count*10 + int(c-'0')
+			if count > MaxRLELength {
+				return nil, fmt.Errorf("rle run length %d is too long", count)
+			}
+			continue
+		case c == 'b' || c == '.':
+			row = append(row, make([]bool, max(count, 1))...)
+		case c == 'o' || c == 'A':
+			for i := 0; i < max(count, 1); i++ {
+				row = append(row, true)
+			}
+		case c == '$':
+			area += len(row)
+			cells = append(cells, row)
+			for i := 1; i < count; i++ {
+				cells = append(cells, []bool{})
+			}
+			row = []bool{}
+		case c == '!':
+			done = true
+		case c == ' ' || c == '\t' || c == '\r':
+		default:
+			return nil, fmt.Errorf("unexpected character %q in rle", c)
+		}
+		if len(row) > MaxRLELength || len(cells) > MaxRLELength || area+len(row) > maxRLECells {
+			return nil, fmt.Errorf("rle pattern is too large")
+		}
+		count = 0
+	}
+	if !done {
+		return nil, fmt.Errorf("rle is missing the terminating '!'")
+	}
+	cells = append(cells, row)
+
+	if declaredW >= 0 {
+		for _, r := range cells {
+			if len(r) > declaredW {
+				return nil, fmt.Errorf("rle row of %d cells exceeds declared width %d", len(r), declaredW)
+			}
+		}
+		if len(cells) > declaredH {
+			return nil, fmt.Errorf("rle has %d rows, more than declared height %d", len(cells), declaredH)
+		}
+	}
+	return cells, nil
+}
+
+// parseRLEHeader parses a line such as "x = 3, y = 3, rule = B3/S23".
+func parseRLEHeader(line string) (int, int, error) {
+	w, h := -1, -1
+	for _, field := range strings.Split(line, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return 0, 0, fmt.Errorf("malformed rle header %q", line)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "x", "y":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return 0, 0, fmt.Errorf("invalid rle %s dimension %q", key, value)
+			}
+			if key == "x" {
+				w = n
+			} else {
+				h = n
+			}
+		case "rule":
+			rule := strings.ToUpper(value)
+			if rule != "B3/S23" && rule != "23/3" {
+				return 0, 0, fmt.Errorf("unsupported rule %q, only B3/S23 is supported", value)
+			}
+		}
+	}
+	if w < 0 || h < 0 {
+		return 0, 0, fmt.Errorf("rle header %q must declare x and y", line)
+	}
+	return w, h, nil
+}
+
+// EncodeRLE writes cells in the run-length encoded format read by ParseRLE,
+// with a header naming rule and lines wrapped at 70 characters.
+func EncodeRLE(cells [][]bool, rule string) string {
+	width := 0
+	for _, row := range cells {
+		width = max(width, len(row))
+	}
+	var out, line strings.Builder
+	fmt.Fprintf(&out, "x = %d, y = %d, rule = %s\n", width, len(cells), rule)
+	emit := func(count int, tag byte) {
+		item := string(tag)
+		if count > 1 {
+			item = strconv.Itoa(count) + item
+		}
+		if line.Len()+len(item) > 70 {
+			out.WriteString(line.String() + "\n")
+			line.Reset()
+		}
+		line.WriteString(item)
+	}
+
+	blankRows := 0
+	for y, row := range cells {
+		// Trailing dead cells are implied by the end of the row.
+		end := len(row)
+		for end > 0 && !row[end-1] {
+			end--
+		}
+		if y > 0 {
+			if end == 0 {
+				blankRows++
+				continue
+			}
+			emit(blankRows+1, '$')
+			blankRows = 0
+		}
+		for x := 0; x < end; {
+			run := 1
+			for x+run < end && row[x+run] == row[x] {
+				run++
+			}
+			tag := byte('b')
+			if row[x] {
+				tag = 'o'
+			}
+			emit(run, tag)
+			x += run
+		}
+	}
+	emit(1, '!')
+	out.WriteString(line.String() + "\n")
+	return out.String()
+}
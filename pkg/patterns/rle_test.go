@@ -0,0 +1,57 @@
+package patterns
+
+import (
+	"image"
+	"testing"
+)
+
+// liveCells lists the positions of the live cells in a grid.
+func liveCells(cells [][]bool) map[image.Point]bool {
+	live := make(map[image.Point]bool)
+	for y, row := range cells {
+		for x, alive := range row {
+			if alive {
+				live[image.Pt(x, y)] = true
+			}
+		}
+	}
+	return live
+}
+
+// FuzzParseRLE checks that ParseRLE never panics on client input and that
+// whatever it accepts survives a round trip through EncodeRLE.
+func FuzzParseRLE(f *testing.F) {
+	for _, seed := range []string{
+		"bo$2bo$3o!",
+		"#N Glider\nx = 3, y = 3, rule = B3/S23\nbo$2bo$3o!",
+		"x = 0, y = 0\n!",
+		"3o$$3o!",
+		"12b3o3$o4bo!",
+		"65000b$65000b!",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if len(s) > MaxRLELength {
+			return // the server refuses longer strings before parsing
+		}
+		cells, err := ParseRLE(s)
+		if err != nil {
+			return
+		}
+		encoded := EncodeRLE(cells, "B3/S23")
+		again, err := ParseRLE(encoded)
+		if err != nil {
+			t.Fatalf("ParseRLE(EncodeRLE(...)) failed: %v\nencoded:\n%s", err, encoded)
+		}
+		want, got := liveCells(cells), liveCells(again)
+		if len(got) != len(want) {
+			t.Fatalf("round trip has %d live cells, want %d\nencoded:\n%s", len(got), len(want), encoded)
+		}
+		for p := range want {
+			if !got[p] {
+				t.Fatalf("round trip lost the cell at %v\nencoded:\n%s", p, encoded)
+			}
+		}
+	})
+}
@@ -0,0 +1,116 @@
+// Package protocol defines the JSON messages exchanged over the Game of
+// Life WebSocket. Every message is an object with a "type" field naming one
+// of the types below, except the board state, which the server sends
+// without one.
+package protocol
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"GameOfLife/pkg/engine"
+)
+
+// Message types sent by clients. Every message names its game in its
+// "gameID" field; Init creates that game or joins it if it exists.
+const (
+	TypeInit               = "init"
+	TypeJoin               = "join"
+	TypeBirth              = "birth"
+	TypeStop               = "stop"
+	TypeResume             = "resume"
+	TypeSetBackgroundColor = "setBackgroundColor"
+	TypeClear              = "clear"
+	TypeRandomBirth        = "randomBirth"
+	TypePattern            = "pattern"
+	TypeSetName            = "setName"
+	TypeStartRound         = "startRound"
+	TypeReady              = "ready"
+	TypeCursor             = "cursor"
+	TypeSelection          = "selection"
+	TypeChat               = "chat"
+	TypeResize             = "resize"
+	TypeViewport           = "viewport"
+)
+
+// Message types sent by the server. Cursor, Selection and Chat are relayed
+// with the sender's player ID and name added.
+const (
+	TypeError       = "error"
+	TypeRole        = "role"
+	TypePresence    = "presence"
+	TypeAction      = "action"
+	TypeScores      = "scores"
+	TypeRound       = "round"
+	TypeResult      = "result"
+	TypeChatHistory = "chatHistory"
+	TypeNotice      = "notice"
+)
+
+// Alive is the smallest cell value in Board that is a live cell. Smaller
+// values are dead cells, holding their number of live neighbours.
+const Alive = engine.Alive
+
+// BoardState is the board state the server broadcasts after every change
+// and generation. Board and Owners hold one base64 string per row, see
+// EncodeRows.
+//
+// A client that subscribed to a viewport receives only the part of the
+// board inside it, described by View; Width and Height remain the size of
+// the whole board. At zoom 1 Board and Owners cover View cell by cell. When
+// zoomed out, Board is null and each byte of Density covers a block of
+// Zoom x Zoom cells, from 0 for an empty block to 255 for a full one, while
+// Owners holds the team owning most of the block's live cells.
+type BoardState struct {
+	Board           []string
+	Width           int
+	Height          int
+	CellSize        int
+	Color           string
+	BackgroundColor string
+	Interval        int64 // shortest time between generations, in nanoseconds
+	Stopped         bool
+	Mode            string
+	Rule            string
+	Owners          []string  `json:",omitempty"`
+	TeamColors      []string  `json:",omitempty"`
+	View            *Viewport `json:",omitempty"`
+	Density         []string  `json:",omitempty"`
+}
+
+// Viewport is a rectangle of the board in cell coordinates, as sent in a
+// "viewport" message and in the View of a board state. Zoom is the number
+// of cells along each side of a block summarised by one byte of Density.
+type Viewport struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Zoom   int `json:"zoom"`
+}
+
+// EncodeRows encodes each row of cells as standard base64, which keeps a
+// board several times smaller than a JSON array of numbers.
+func EncodeRows(rows [][]uint8) []string {
+	if rows == nil {
+		return nil
+	}
+	encoded := make([]string, len(rows))
+	for i, row := range rows {
+		encoded[i] = base64.StdEncoding.EncodeToString(row)
+	}
+	return encoded
+}
+
+// DecodeRows reverses EncodeRows.
+func DecodeRows(encoded []string) ([][]uint8, error) {
+	rows := make([][]uint8, len(encoded))
+	for i, row := range encoded {
+		b, err := base64.StdEncoding.DecodeString(row)
+		if err != nil {
+			return nil, fmt.Errorf("decode row %d: %w", i, err)
+		}
+		rows[i] = b
+	}
+	return rows, nil
+}
@@ -0,0 +1,372 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"GameOfLife/pkg/engine"
+	"GameOfLife/pkg/patterns"
+	"GameOfLife/pkg/protocol"
+)
+
+// Bounds on the generation interval an operator may set.
+const (
+	minGameInterval = 50 * time.Millisecond
+	maxGameInterval = time.Minute
+)
+
+// adminGame is a game as listed by the admin API.
+type adminGame struct {
+	ID        string        `json:"id"`
+	Mode      string        `json:"mode"`
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	Rule      string        `json:"rule"`
+	Interval  string        `json:"interval"`
+	Stopped   bool          `json:"stopped"`
+	LiveCells int           `json:"liveCells"`
+	Phase     string        `json:"phase,omitempty"`
+	Players   []adminPlayer `json:"players"`
+}
+
+// adminPlayer is a connection as listed by the admin API.
+type adminPlayer struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	Team       int    `json:"team,omitempty"`
+	RemoteAddr string `json:"remoteAddr"`
+}
+
+// registerAdmin adds the admin API to mux. It is only registered when an
+// admin token is configured, and every endpoint requires that token.
+func (s *Server) registerAdmin(mux *http.ServeMux) {
+	protect := func(h http.HandlerFunc) http.Handler { return requireAuth(s.cfg.AdminToken, h) }
+	mux.Handle("GET /admin/api/games", protect(s.adminListGames))
+	mux.Handle("GET /admin/api/games/{id}", protect(s.adminGetGame))
+	mux.Handle("PATCH /admin/api/games/{id}", protect(s.adminUpdateGame))
+	mux.Handle("DELETE /admin/api/games/{id}", protect(s.adminDeleteGame))
+	mux.Handle("POST /admin/api/games/{id}/stop", protect(s.adminStopGame))
+	mux.Handle("POST /admin/api/games/{id}/resume", protect(s.adminResumeGame))
+	mux.Handle("POST /admin/api/games/{id}/step", protect(s.adminStepGame))
+	mux.Handle("GET /admin/api/games/{id}/board", protect(s.adminDumpBoard))
+	mux.Handle("DELETE /admin/api/clients/{id}", protect(s.adminKickClient))
+	mux.Handle("POST /admin/api/notice", protect(s.adminNotice))
+}
+
+// describeGame summarises a game for the admin API. The caller must hold
+// s.mu.
+func (s *Server) describeGame(gameID string, game *Game) adminGame {
+	game.mu.Lock()
+	desc := adminGame{
+		ID:       gameID,
+		Mode:     game.Mode,
+		Width:    game.Width,
+		Height:   game.Height,
+		Rule:     game.Rule.String(),
+		Interval: time.Duration(game.Interval).String(),
+		Stopped:  game.Stopped,
+		Players:  []adminPlayer{},
+	}
+	if game.Round != nil {
+		desc.Phase = game.Round.Phase
+	}
+	desc.LiveCells = game.LiveCells()
+	game.mu.Unlock()
+
+	var members []*Client
+	for client := range s.clients {
+		if client.gameID == gameID {
+			members = append(members, client)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].seq < members[j].seq })
+	for _, client := range members {
+		desc.Players = append(desc.Players, adminPlayer{
+			ID:         client.id,
+			Name:       client.name,
+			Role:       client.role,
+			Team:       client.team,
+			RemoteAddr: client.conn.RemoteAddr().String(),
+		})
+	}
+	return desc
+}
+
+// writeJSON sends v as the JSON response body.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("writing admin response failed", "err", err)
+	}
+}
+
+// adminListGames lists every game with its settings and players.
+func (s *Server) adminListGames(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	list := make([]adminGame, 0, len(s.games))
+	for gameID, game := range s.games {
+		list = append(list, s.describeGame(gameID, game))
+	}
+	s.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	writeJSON(w, list)
+}
+
+// adminGetGame describes a single game.
+func (s *Server) adminGetGame(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	desc := s.describeGame(gameID, game)
+	s.mu.Unlock()
+	writeJSON(w, desc)
+}
+
+// adminUpdateGame changes a game's rule or interval, given as JSON such as
+// {"rule": "B36/S23", "interval": "250ms"}. Either field may be left out.
+func (s *Server) adminUpdateGame(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Rule     *string `json:"rule"`
+		Interval *string `json:"interval"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var rule engine.Rule
+	if req.Rule != nil {
+		if game.Mode != modeClassic {
+			http.Error(w, fmt.Sprintf("the rule of a %s game cannot be changed", game.Mode), http.StatusConflict)
+			return
+		}
+		parsed, err := engine.ParseRule(*req.Rule)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rule = parsed
+	}
+	var interval time.Duration
+	if req.Interval != nil {
+		parsed, err := time.ParseDuration(*req.Interval)
+		if err != nil || parsed < minGameInterval || parsed > maxGameInterval {
+			http.Error(w, fmt.Sprintf("interval must be a duration between %v and %v", minGameInterval, maxGameInterval), http.StatusBadRequest)
+			return
+		}
+		interval = parsed
+	}
+
+	game.mu.Lock()
+	if req.Rule != nil {
+		game.Rule = rule
+	}
+	if req.Interval != nil {
+		game.Interval = int64(interval)
+	}
+	game.mu.Unlock()
+	slog.Info("game settings changed by operator", "gameID", gameID, "rule", req.Rule != nil, "interval", req.Interval != nil, "remoteAddr", r.RemoteAddr)
+	s.broadcastGameState(game, gameID)
+	s.adminGetGame(w, r)
+}
+
+// adminDeleteGame removes a game and disconnects its players.
+func (s *Server) adminDeleteGame(w http.ResponseWriter, r *http.Request) {
+	gameID := r.PathValue("id")
+	s.mu.Lock()
+	if _, exists := s.games[gameID]; !exists {
+		s.mu.Unlock()
+		http.Error(w, fmt.Sprintf("game %q not found", gameID), http.StatusNotFound)
+		return
+	}
+	s.removeGame(gameID)
+	var players []*Client
+	for client := range s.clients {
+		if client.gameID == gameID {
+			players = append(players, client)
+		}
+	}
+	s.mu.Unlock()
+	for _, client := range players {
+		s.closeClient(client, websocket.CloseNormalClosure, "game deleted by an operator")
+	}
+	slog.Info("game deleted by operator", "gameID", gameID, "players", len(players), "remoteAddr", r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminStopGame stops a game, whatever its players are doing.
+func (s *Server) adminStopGame(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	game.mu.Lock()
+	game.Stopped = true
+	game.mu.Unlock()
+	slog.Info("game stopped by operator", "gameID", gameID, "remoteAddr", r.RemoteAddr)
+	s.broadcastGameState(game, gameID)
+	s.adminGetGame(w, r)
+}
+
+// adminResumeGame restarts a stopped game. Territory games can only be
+// resumed while a round is running, as their rounds start themselves.
+func (s *Server) adminResumeGame(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	game.mu.Lock()
+	if game.Round != nil && game.Round.Phase != phaseRunning {
+		game.mu.Unlock()
+		http.Error(w, "territory games can only be resumed while a round is running", http.StatusConflict)
+		return
+	}
+	game.Stopped = false
+	game.mu.Unlock()
+	slog.Info("game resumed by operator", "gameID", gameID, "remoteAddr", r.RemoteAddr)
+	s.broadcastGameState(game, gameID)
+	s.adminGetGame(w, r)
+}
+
+// adminStepGame advances a stopped game by one generation. A running game
+// is refused, as the game loop is already advancing it.
+func (s *Server) adminStepGame(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	game.mu.Lock()
+	stopped, phase := game.Stopped, ""
+	if game.Round != nil {
+		phase = game.Round.Phase
+	}
+	game.mu.Unlock()
+	switch {
+	case phase != "" && phase != phaseRunning:
+		http.Error(w, "territory games can only be stepped while a round is running", http.StatusConflict)
+		return
+	case !stopped:
+		http.Error(w, "only stopped games can be stepped", http.StatusConflict)
+		return
+	}
+	s.advanceGame(game, gameID)
+	slog.Info("game stepped by operator", "gameID", gameID, "remoteAddr", r.RemoteAddr)
+	s.adminGetGame(w, r)
+}
+
+// adminDumpBoard serves the playable area of a game's board, as plaintext
+// (".cells", the default) or with ?format=rle as RLE.
+func (s *Server) adminDumpBoard(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	sim := game.snapshot()
+	cells := make([][]bool, max(sim.Height-2, 0))
+	for y := range cells {
+		cells[y] = make([]bool, max(sim.Width-2, 0))
+		for x := range cells[y] {
+			cells[y][x] = sim.Alive(x+1, y+1)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	switch format := r.URL.Query().Get("format"); format {
+	case "rle":
+		fmt.Fprintf(w, "#N %s\n", gameID)
+		fmt.Fprint(w, patterns.EncodeRLE(cells, sim.Rule.String()))
+	case "", "cells":
+		fmt.Fprintf(w, "!Name: %s\n!Rule: %s\n", gameID, sim.Rule)
+		var line strings.Builder
+		for _, row := range cells {
+			line.Reset()
+			for _, alive := range row {
+				if alive {
+					line.WriteByte('O')
+				} else {
+					line.WriteByte('.')
+				}
+			}
+			fmt.Fprintln(w, line.String())
+		}
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q, want cells or rle", format), http.StatusBadRequest)
+		return
+	}
+	slog.Info("board dumped for operator", "gameID", gameID, "remoteAddr", r.RemoteAddr)
+}
+
+// adminKickClient disconnects a player by ID with close code 1008.
+func (s *Server) adminKickClient(w http.ResponseWriter, r *http.Request) {
+	clientID := r.PathValue("id")
+	s.mu.Lock()
+	var target *Client
+	for client := range s.clients {
+		if client.id == clientID {
+			target = client
+			break
+		}
+	}
+	s.mu.Unlock()
+	if target == nil {
+		http.Error(w, fmt.Sprintf("client %q not found", clientID), http.StatusNotFound)
+		return
+	}
+	s.closeClient(target, websocket.ClosePolicyViolation, "kicked by an operator")
+	slog.Info("client kicked by operator", "gameID", target.gameID, "clientID", clientID, "remoteAddr", r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminNotice sends a notice, given as JSON such as {"text": "Restarting
+// in 5 minutes"}, to every player in every game.
+func (s *Server) adminNotice(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4*maxChatLength+64))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	text := strings.TrimSpace(stripControl(req.Text))
+	if n := len([]rune(text)); n == 0 || n > maxChatLength {
+		http.Error(w, fmt.Sprintf("notice text must be 1 to %d characters", maxChatLength), http.StatusBadRequest)
+		return
+	}
+
+	notice := map[string]interface{}{"type": protocol.TypeNotice, "text": text, "time": time.Now().UTC()}
+	s.mu.Lock()
+	gameIDs := make(map[string]bool)
+	recipients := 0
+	for client := range s.clients {
+		if client.gameID != "" {
+			gameIDs[client.gameID] = true
+			recipients++
+		}
+	}
+	for gameID := range gameIDs {
+		s.broadcastMessage(gameID, notice)
+	}
+	s.mu.Unlock()
+	slog.Info("notice sent by operator", "text", text, "recipients", recipients, "remoteAddr", r.RemoteAddr)
+	writeJSON(w, map[string]int{"recipients": recipients})
+}
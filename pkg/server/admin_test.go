@@ -0,0 +1,154 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// newAdminServer serves a server whose admin token is "admin" until the
+// test ends.
+func newAdminServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.AdminToken = "admin"
+	s := New(cfg)
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// adminRequest sends an admin API request with the token "admin" and
+// returns the status and body of the response.
+func adminRequest(t *testing.T, srv *httptest.Server, method, path string) (int, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer admin")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	_, srv := newAdminServer(t)
+	resp, err := http.Get(srv.URL + "/admin/api/games")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status without the token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestAdminStopAndStep(t *testing.T) {
+	s, srv := newAdminServer(t)
+	game := newGame(10, 10, 5, "white", "black", int64(time.Second))
+	for y := 3; y <= 5; y++ {
+		game.Birth(4, y) // a vertical blinker
+	}
+	s.games["g"] = game
+
+	if status, body := adminRequest(t, srv, "POST", "/admin/api/games/g/step"); status != http.StatusConflict {
+		t.Errorf("stepping a running game: status %d (%s), want %d", status, body, http.StatusConflict)
+	}
+
+	status, body := adminRequest(t, srv, "POST", "/admin/api/games/g/stop")
+	var desc adminGame
+	json.Unmarshal(body, &desc)
+	if status != http.StatusOK || !desc.Stopped {
+		t.Fatalf("stop: status %d, stopped %v; want %d and stopped", status, desc.Stopped, http.StatusOK)
+	}
+
+	status, body = adminRequest(t, srv, "POST", "/admin/api/games/g/step")
+	desc = adminGame{}
+	json.Unmarshal(body, &desc)
+	if status != http.StatusOK || !desc.Stopped || desc.LiveCells != 3 {
+		t.Fatalf("step: status %d, stopped %v, %d live cells; want %d, stopped and 3", status, desc.Stopped, desc.LiveCells, http.StatusOK)
+	}
+	game.mu.Lock()
+	horizontal := game.Alive(3, 4) && game.Alive(4, 4) && game.Alive(5, 4)
+	game.mu.Unlock()
+	if !horizontal {
+		t.Error("the blinker did not advance by one generation")
+	}
+
+	if status, _ := adminRequest(t, srv, "POST", "/admin/api/games/missing/step"); status != http.StatusNotFound {
+		t.Errorf("stepping a missing game: status %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestAdminTerritoryOutsideRound(t *testing.T) {
+	s, srv := newAdminServer(t)
+	game := newGame(10, 10, 5, "white", "black", int64(time.Second))
+	game.enableTeams(modeTerritory)
+	game.Round = &Round{Phase: phaseWaiting}
+	game.Stopped = true
+	s.games["t"] = game
+
+	for _, action := range []string{"step", "resume"} {
+		if status, _ := adminRequest(t, srv, "POST", "/admin/api/games/t/"+action); status != http.StatusConflict {
+			t.Errorf("%s outside a round: status %d, want %d", action, status, http.StatusConflict)
+		}
+	}
+}
+
+func TestDeleteGameStopsRoundTimer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = "admin"
+	s := New(cfg)
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	owner := dial(t, srv)
+	owner.send(map[string]interface{}{"type": protocol.TypeInit, "gameID": "t", "width": 20, "height": 10, "cellSize": 5, "fill": "empty", "mode": modeTerritory, "setupSeconds": 1})
+	var role struct {
+		InviteToken string `json:"inviteToken"`
+	}
+	json.Unmarshal(owner.next(protocol.TypeRole), &role)
+	rival := dial(t, srv)
+	rival.send(map[string]interface{}{"type": protocol.TypeJoin, "gameID": "t", "token": role.InviteToken})
+	rival.state()
+	owner.send(map[string]interface{}{"type": protocol.TypeStartRound, "gameID": "t"})
+	for {
+		var round struct {
+			Phase string `json:"phase"`
+		}
+		json.Unmarshal(owner.next(protocol.TypeRound), &round)
+		if round.Phase == phaseSetup {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	game := s.games["t"]
+	s.mu.Unlock()
+	if status, _ := adminRequest(t, srv, "DELETE", "/admin/api/games/t"); status != http.StatusNoContent {
+		t.Fatalf("delete: status %d, want %d", status, http.StatusNoContent)
+	}
+	time.Sleep(1200 * time.Millisecond) // past the end of the setup phase
+	game.mu.Lock()
+	phase := game.Round.Phase
+	game.mu.Unlock()
+	if phase != phaseSetup {
+		t.Errorf("deleted game's round moved on to %q", phase)
+	}
+	if status, _ := adminRequest(t, srv, "GET", "/admin/api/games/t"); status != http.StatusNotFound {
+		t.Errorf("deleted game: status %d, want %d", status, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// Chat limits: the longest message in runes, the number of messages kept
+// per game for players who join later, and the shortest gap between two
+// messages from one client.
+const (
+	maxChatLength  = 500
+	chatScrollback = 50
+	chatInterval   = 500 * time.Millisecond
+)
+
+// chatMessage is one line of a game's chat, as broadcast and as kept in its
+// scrollback.
+type chatMessage struct {
+	Type     string    `json:"type"`
+	PlayerID string    `json:"playerID"`
+	Name     string    `json:"name"`
+	Text     string    `json:"text"`
+	Time     time.Time `json:"time"`
+}
+
+// parseChat returns the cleaned text of a "chat" message. Control characters
+// are dropped and surrounding space is trimmed.
+func parseChat(msg map[string]interface{}) (string, error) {
+	text, ok := msg["text"].(string)
+	if !ok {
+		return "", fmt.Errorf("chat needs a text field")
+	}
+	text = strings.TrimSpace(stripControl(text))
+	switch n := len([]rune(text)); {
+	case n == 0:
+		return "", fmt.Errorf("chat message is empty")
+	case n > maxChatLength:
+		return "", fmt.Errorf("chat message is longer than %d characters", maxChatLength)
+	}
+	return text, nil
+}
+
+// sendChat broadcasts a chat message to everyone in the game, including the
+// sender, and appends it to the game's scrollback.
+func (s *Server) sendChat(client *Client, game *Game, gameID string, msg map[string]interface{}) error {
+	text, err := parseChat(msg)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(client.chatAt) < chatInterval {
+		return fmt.Errorf("chat messages are limited to one every %v", chatInterval)
+	}
+	client.chatAt = now
+	line := chatMessage{Type: protocol.TypeChat, PlayerID: client.id, Name: client.name, Text: text, Time: now.UTC()}
+
+	game.mu.Lock()
+	game.chat = append(game.chat, line)
+	if len(game.chat) > chatScrollback {
+		game.chat = game.chat[len(game.chat)-chatScrollback:]
+	}
+	game.mu.Unlock()
+
+	s.broadcastMessage(gameID, line)
+	slog.Debug("chat message", "gameID", gameID, "clientID", client.id, "length", len([]rune(text)))
+	return nil
+}
+
+// sendChatHistory gives a client that has just joined the game's recent chat.
+func (s *Server) sendChatHistory(client *Client, game *Game) {
+	game.mu.Lock()
+	history := make([]chatMessage, len(game.chat))
+	copy(history, game.chat)
+	game.mu.Unlock()
+	if len(history) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply := map[string]interface{}{"type": protocol.TypeChatHistory, "messages": history}
+	if err := client.conn.WriteJSON(reply); err != nil {
+		slog.Warn("sending chat history failed", "gameID", client.gameID, "clientID", client.id, "err", err)
+	}
+}
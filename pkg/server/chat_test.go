@@ -0,0 +1,38 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseChat(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    interface{}
+		want    string
+		wantErr string
+	}{
+		{"plain", "hello", "hello", ""},
+		{"trimmed", "  hello \n", "hello", ""},
+		{"control characters", "he\x1b[2Jl\x00lo", "he[2Jllo", ""},
+		{"empty", "   ", "", "empty"},
+		{"only control characters", "\x07\x08", "", "empty"},
+		{"too long", strings.Repeat("é", maxChatLength+1), "", "longer than"},
+		{"longest allowed", strings.Repeat("é", maxChatLength), strings.Repeat("é", maxChatLength), ""},
+		{"not a string", 42.0, "", "needs a text field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChat(map[string]interface{}{"type": "chat", "text": tt.text})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseChat() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseChat() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// Game modes. In the competitive modes every live cell belongs to a team
+// and a newborn cell takes the colour most of its three parents share.
+const (
+	modeClassic     = "classic"
+	modeImmigration = "immigration" // two teams
+	modeQuadLife    = "quadlife"    // four teams
+)
+
+// teamColors are the cell colours of each team, indexed by team - 1.
+var teamColors = map[string][]string{
+	modeImmigration: {"#e74c3c", "#3498db"},
+	modeQuadLife:    {"#e74c3c", "#2ecc71", "#3498db", "#f1c40f"},
+	modeTerritory:   {"#e74c3c", "#3498db"},
+}
+
+// parseMode reads the optional "mode" field of an "init" message.
+func parseMode(msg map[string]interface{}) (string, error) {
+	v, ok := msg["mode"]
+	if !ok {
+		return modeClassic, nil
+	}
+	mode, _ := v.(string)
+	switch mode {
+	case modeClassic, modeImmigration, modeQuadLife, modeTerritory:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown mode %v", v)
+}
+
+// enableTeams switches a new game into a competitive mode. Cells already on
+// the board from the initial fill are shared out between the teams at
+// random.
+func (g *Game) enableTeams(mode string) {
+	g.Mode = mode
+	if mode != modeClassic {
+		g.EnableTeams(len(teamColors[mode]))
+	}
+}
+
+// teamPopulations counts live cells per team, indexed by team - 1.
+func (g *Game) teamPopulations() []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.CountTeams()
+}
+
+// assignTeam puts a player on the team with the fewest players in a
+// competitive game. Spectators stay without a team, as do players beyond
+// the first two in a territory game. The caller must hold s.mu.
+func (s *Server) assignTeam(client *Client, game *Game) {
+	client.team = 0
+	teams := len(teamColors[game.Mode])
+	if teams == 0 || !client.canEdit() {
+		return
+	}
+	players := make([]int, teams)
+	for other := range s.clients {
+		if other != client && other.gameID == client.gameID && other.team > 0 {
+			players[other.team-1]++
+		}
+	}
+	best := 0
+	for team := range players {
+		if players[team] < players[best] {
+			best = team
+		}
+	}
+	if game.Mode == modeTerritory && players[best] > 0 {
+		return
+	}
+	client.team = best + 1
+}
+
+// scoreEntry is one player's line in the scores broadcast.
+type scoreEntry struct {
+	PlayerID string `json:"playerID"`
+	Name     string `json:"name"`
+	Team     int    `json:"team"`
+	Color    string `json:"color"`
+	Cells    int    `json:"cells"`
+}
+
+// broadcastScores sends every player's population, the number of live cells
+// in their team's colour, after a generation of a competitive game.
+func (s *Server) broadcastScores(game *Game, gameID string) {
+	populations := game.teamPopulations()
+	colors := teamColors[game.Mode]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var scores []scoreEntry
+	for client := range s.clients {
+		if client.gameID == gameID && client.team > 0 {
+			scores = append(scores, scoreEntry{
+				PlayerID: client.id,
+				Name:     client.name,
+				Team:     client.team,
+				Color:    colors[client.team-1],
+				Cells:    populations[client.team-1],
+			})
+		}
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Cells != scores[j].Cells {
+			return scores[i].Cells > scores[j].Cells
+		}
+		return scores[i].PlayerID < scores[j].PlayerID
+	})
+	s.broadcastMessage(gameID, map[string]interface{}{
+		"type":   protocol.TypeScores,
+		"gameID": gameID,
+		"teams":  populations,
+		"scores": scores,
+	})
+	s.logTick("team populations", "gameID", gameID, "populations", populations)
+}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"GameOfLife/pkg/patterns"
+)
+
+// Config holds the server settings. Each one can be given, from lowest to
+// highest precedence, in a config file, as a GOL_* environment variable or
+// as a command line flag.
+type Config struct {
+	Addr           string
+	AssetDir       string
+	AllowedOrigins string
+	AuthToken      string
+	AdminToken     string
+	TLSCert        string
+	TLSKey         string
+
+	Tick              time.Duration // pause after each game in the game loop
+	DefaultColor      string
+	DefaultBackground string
+	DefaultInterval   time.Duration
+
+	MaxWidth       int
+	MaxHeight      int
+	MaxMessageSize int64
+
+	ShutdownTimeout time.Duration
+	StateFile       string // games are saved here on shutdown and restored on start
+
+	LogLevel  string
+	LogFormat string
+	LogSample int // keep one in this many per-tick debug events
+	Debug     bool
+}
+
+// DefaultConfig returns the settings used when nothing else is given.
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":8080",
+		AssetDir:          "./assets",
+		Tick:              500 * time.Millisecond,
+		DefaultColor:      "#ccc",
+		DefaultBackground: "#111",
+		DefaultInterval:   500 * time.Millisecond,
+		MaxWidth:          2000,
+		MaxHeight:         2000,
+		MaxMessageSize:    2 * patterns.MaxRLELength, // room for the longest RLE pattern
+		ShutdownTimeout:   10 * time.Second,
+		LogLevel:          "info",
+		LogFormat:         "text",
+		LogSample:         100,
+	}
+}
+
+// logLevels are the accepted values of the log-level setting.
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// flagSet binds every setting of c to a flag. The flag names double as the
+// config file keys and, upper-cased with a GOL_ prefix, as environment
+// variable names.
+func (c *Config) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(&c.Addr, "addr", c.Addr, "address to listen on")
+	fs.StringVar(&c.AssetDir, "asset-dir", c.AssetDir, "directory holding the browser client")
+	fs.StringVar(&c.AllowedOrigins, "allowed-origins", c.AllowedOrigins, "comma-separated origins allowed to open WebSockets, * for any (default: same host only)")
+	fs.StringVar(&c.AuthToken, "auth-token", c.AuthToken, "shared secret required on /ws and /api, as a Bearer token or ?auth= parameter")
+	fs.StringVar(&c.AdminToken, "admin-token", c.AdminToken, "secret required on /admin/api, which is disabled without one")
+	fs.StringVar(&c.TLSCert, "tls-cert", c.TLSCert, "TLS certificate file; serves HTTPS and WSS together with -tls-key")
+	fs.StringVar(&c.TLSKey, "tls-key", c.TLSKey, "TLS private key file")
+	fs.DurationVar(&c.Tick, "tick", c.Tick, "pause after updating each game")
+	fs.StringVar(&c.DefaultColor, "default-color", c.DefaultColor, "cell colour of new games")
+	fs.StringVar(&c.DefaultBackground, "default-background", c.DefaultBackground, "background colour of new games")
+	fs.DurationVar(&c.DefaultInterval, "default-interval", c.DefaultInterval, "shortest time between generations of new games")
+	fs.IntVar(&c.MaxWidth, "max-width", c.MaxWidth, "largest board width a client may create")
+	fs.IntVar(&c.MaxHeight, "max-height", c.MaxHeight, "largest board height a client may create")
+	fs.Int64Var(&c.MaxMessageSize, "max-message-size", c.MaxMessageSize, "largest WebSocket message in bytes")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "how long a graceful shutdown may take before the server exits anyway")
+	fs.StringVar(&c.StateFile, "state-file", c.StateFile, "save games to this JSON file on shutdown and restore them on start")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "log level: "+strings.Join(logLevels, ", "))
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "log output format: text or json")
+	fs.IntVar(&c.LogSample, "log-sample", c.LogSample, "log one in this many per-tick debug events")
+	fs.BoolVar(&c.Debug, "debug", c.Debug, "serve pprof profiles and lock contention under /debug")
+	return fs
+}
+
+// envName is the environment variable for a setting, e.g. GOL_AUTH_TOKEN.
+func envName(name string) string {
+	return "GOL_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// LoadConfig builds the configuration from args (without the program name),
+// the environment and the config file named by -config or GOL_CONFIG. It
+// returns printConfig = true if -print-config was given.
+func LoadConfig(args []string, getenv func(string) string) (c Config, printConfig bool, err error) {
+	c = DefaultConfig()
+	fs := c.flagSet()
+	configFile := fs.String("config", getenv("GOL_CONFIG"), "optional TOML or YAML config file")
+	fs.BoolVar(&printConfig, "print-config", false, "print the effective configuration and exit")
+	if err := fs.Parse(args); err != nil {
+		return c, false, err
+	}
+	if fs.NArg() > 0 {
+		return c, false, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	onCommandLine := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { onCommandLine[f.Name] = true })
+
+	if *configFile != "" {
+		values, err := readConfigFile(*configFile)
+		if err != nil {
+			return c, false, err
+		}
+		for _, kv := range values {
+			if fs.Lookup(kv.key) == nil || kv.key == "config" || kv.key == "print-config" {
+				return c, false, fmt.Errorf("%s:%d: unknown setting %q", *configFile, kv.line, kv.key)
+			}
+			if onCommandLine[kv.key] || getenv(envName(kv.key)) != "" {
+				continue
+			}
+			if err := fs.Set(kv.key, kv.value); err != nil {
+				return c, false, fmt.Errorf("%s:%d: %v", *configFile, kv.line, err)
+			}
+		}
+	}
+	var envErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		value := getenv(envName(f.Name))
+		if value == "" || onCommandLine[f.Name] || f.Name == "config" || f.Name == "print-config" || envErr != nil {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			envErr = fmt.Errorf("%s: %v", envName(f.Name), err)
+		}
+	})
+	if envErr != nil {
+		return c, false, envErr
+	}
+	return c, printConfig, c.validate()
+}
+
+// validate checks the settings for values the server cannot run with.
+func (c *Config) validate() error {
+	var errs []error
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr must not be empty"))
+	}
+	if info, err := os.Stat(c.AssetDir); err != nil || !info.IsDir() {
+		errs = append(errs, fmt.Errorf("asset-dir %q is not a directory", c.AssetDir))
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, errors.New("tls-cert and tls-key must be given together"))
+	}
+	if c.Tick < 10*time.Millisecond {
+		errs = append(errs, fmt.Errorf("tick %v is shorter than 10ms", c.Tick))
+	}
+	if c.DefaultInterval <= 0 {
+		errs = append(errs, fmt.Errorf("default-interval %v must be positive", c.DefaultInterval))
+	}
+	for name, color := range map[string]string{"default-color": c.DefaultColor, "default-background": c.DefaultBackground} {
+		if _, ok := lookupColor(color); !ok {
+			errs = append(errs, fmt.Errorf("%s %q is not a colour name or #rgb/#rrggbb", name, color))
+		}
+	}
+	if c.MaxWidth < 3 || c.MaxHeight < 3 {
+		errs = append(errs, fmt.Errorf("max-width and max-height must be at least 3, got %dx%d", c.MaxWidth, c.MaxHeight))
+	}
+	if c.MaxMessageSize < 1024 {
+		errs = append(errs, fmt.Errorf("max-message-size %d is smaller than 1024 bytes", c.MaxMessageSize))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown-timeout %v must be positive", c.ShutdownTimeout))
+	}
+	if !slices.Contains(logLevels, c.LogLevel) {
+		errs = append(errs, fmt.Errorf("log-level %q is not one of %s", c.LogLevel, strings.Join(logLevels, ", ")))
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("log-format %q is not text or json", c.LogFormat))
+	}
+	if c.LogSample < 1 {
+		errs = append(errs, fmt.Errorf("log-sample %d must be at least 1", c.LogSample))
+	}
+	return errors.Join(errs...)
+}
+
+// Print writes the configuration in the config file format. The auth and
+// admin tokens are redacted.
+func (c *Config) Print(w io.Writer) {
+	fs := c.flagSet()
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if (f.Name == "auth-token" || f.Name == "admin-token") && value != "" {
+			value = "<redacted>"
+		}
+		_, err := strconv.ParseInt(value, 10, 64)
+		if _, isBool := f.Value.(interface{ IsBoolFlag() bool }); err != nil && !isBool {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(w, "%s = %s\n", f.Name, value)
+	})
+}
+
+// configValue is one "key = value" line of a config file.
+type configValue struct {
+	key, value string
+	line       int
+}
+
+// readConfigFile reads a flat config file: TOML "key = value" lines, or YAML
+// "key: value" lines for files ending in .yaml or .yml. Values may be
+// quoted, "#" starts a comment, and a TOML or YAML list of strings is
+// joined with commas, which is how allowed-origins takes several origins.
+func readConfigFile(path string) ([]configValue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	separator := "="
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		separator = ":"
+	}
+	var values []configValue
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		key, raw, ok := strings.Cut(line, separator)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key %s value", path, n, separator)
+		}
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		values = append(values, configValue{key: strings.TrimSpace(key), value: value, line: n})
+	}
+	return values, scanner.Err()
+}
+
+// parseConfigValue unquotes a value, strips a trailing comment and joins
+// lists with commas.
+func parseConfigValue(raw string) (string, error) {
+	if strings.HasPrefix(raw, "[") {
+		end := strings.LastIndex(raw, "]")
+		if end < 0 {
+			return "", errors.New("unterminated list")
+		}
+		var items []string
+		for _, item := range strings.Split(raw[1:end], ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v, err := parseConfigValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, v)
+		}
+		return strings.Join(items, ","), nil
+	}
+	if strings.HasPrefix(raw, "'") {
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated string")
+		}
+		return raw[1 : end+1], nil
+	}
+	if strings.HasPrefix(raw, `"`) {
+		prefix, err := strconv.QuotedPrefix(raw)
+		if err != nil {
+			return "", fmt.Errorf("bad string %s", raw)
+		}
+		return strconv.Unquote(prefix)
+	}
+	if i := strings.Index(raw, "#"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// cursorInterval is the shortest gap between two relayed cursor or selection
+// messages from one client. Faster messages are dropped, as the next one
+// supersedes them anyway.
+const cursorInterval = 50 * time.Millisecond
+
+// parseCursor reads the position of a "cursor" message, or the rectangle of
+// a "selection" message, and clips it to the playable area of a width x
+// height board. A selection with a zero width or height clears the player's
+// selection.
+func parseCursor(width, height int, msg map[string]interface{}) (map[string]interface{}, error) {
+	names := []string{"x", "y"}
+	if msg["type"] == protocol.TypeSelection {
+		names = append(names, "width", "height")
+	}
+	values := make(map[string]int, len(names))
+	for _, name := range names {
+		v, ok := msg[name].(float64)
+		if !ok {
+			return nil, fmt.Errorf("%v needs a numeric %s", msg["type"], name)
+		}
+		values[name] = int(v)
+	}
+	x := min(max(values["x"], 1), width-2)
+	y := min(max(values["y"], 1), height-2)
+	relay := map[string]interface{}{"x": x, "y": y}
+	if msg["type"] == protocol.TypeSelection {
+		relay["width"] = min(max(values["width"], 0), width-1-x)
+		relay["height"] = min(max(values["height"], 0), height-1-y)
+	}
+	return relay, nil
+}
+
+// relayCursor forwards a player's cursor or selection to the other players
+// in the game. Nothing is stored: a client that joins later sees the cursor
+// on its next move.
+func (s *Server) relayCursor(client *Client, game *Game, gameID string, msg map[string]interface{}) error {
+	// Resizes change the board size under game.mu.
+	game.mu.Lock()
+	width, height := game.Width, game.Height
+	game.mu.Unlock()
+	relay, err := parseCursor(width, height, msg)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last := &client.cursorAt
+	if msg["type"] == protocol.TypeSelection {
+		last = &client.selectionAt
+	}
+	now := time.Now()
+	if now.Sub(*last) < cursorInterval {
+		s.metrics.droppedMessages.add("cursor_throttle", 1)
+		return nil
+	}
+	*last = now
+	relay["type"] = msg["type"]
+	relay["playerID"] = client.id
+	relay["name"] = client.name
+	if client.team > 0 {
+		relay["team"] = client.team
+	}
+	for other := range s.clients {
+		if other != client && other.gameID == gameID {
+			if err := other.conn.WriteJSON(relay); err != nil {
+				slog.Warn("relaying cursor failed", "gameID", gameID, "clientID", other.id, "err", err)
+				s.metrics.droppedMessages.add("write_error", 1)
+				other.conn.Close()
+				delete(s.clients, other)
+			}
+		}
+	}
+	return nil
+}
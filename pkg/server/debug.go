@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// trackedMutex is a sync.Mutex that counts how often Lock had to wait for
+// another holder and for how long, for the /debug/locks report.
+type trackedMutex struct {
+	sync.Mutex
+	acquired  atomic.Uint64
+	contended atomic.Uint64
+	waited    atomic.Int64 // nanoseconds
+	maxWait   atomic.Int64 // nanoseconds
+}
+
+// Lock locks m, recording the wait if the lock was already held.
+func (m *trackedMutex) Lock() {
+	m.acquired.Add(1)
+	if m.TryLock() {
+		return
+	}
+	start := time.Now()
+	m.Mutex.Lock()
+	wait := int64(time.Since(start))
+	m.contended.Add(1)
+	m.waited.Add(wait)
+	for {
+		longest := m.maxWait.Load()
+		if wait <= longest || m.maxWait.CompareAndSwap(longest, wait) {
+			break
+		}
+	}
+}
+
+// lockStats is the JSON form of a trackedMutex's counters.
+type lockStats struct {
+	Acquired       uint64  `json:"acquired"`
+	Contended      uint64  `json:"contended"`
+	WaitSeconds    float64 `json:"waitSeconds"`
+	MaxWaitSeconds float64 `json:"maxWaitSeconds"`
+}
+
+func (m *trackedMutex) stats() lockStats {
+	return lockStats{
+		Acquired:       m.acquired.Load(),
+		Contended:      m.contended.Load(),
+		WaitSeconds:    time.Duration(m.waited.Load()).Seconds(),
+		MaxWaitSeconds: time.Duration(m.maxWait.Load()).Seconds(),
+	}
+}
+
+// registerDebug adds the /debug endpoints to mux: the net/http/pprof
+// profiles, and a JSON report of goroutines and lock contention. They are
+// only registered when cfg.Debug is set, and need the auth token like the
+// rest of the API.
+func (s *Server) registerDebug(mux *http.ServeMux) {
+	runtime.SetMutexProfileFraction(5)
+	runtime.SetBlockProfileRate(int(time.Millisecond))
+
+	protect := func(h http.HandlerFunc) http.Handler { return requireAuth(s.cfg.AuthToken, h) }
+	mux.Handle("/debug/pprof/", protect(pprof.Index))
+	mux.Handle("/debug/pprof/cmdline", protect(pprof.Cmdline))
+	mux.Handle("/debug/pprof/profile", protect(pprof.Profile))
+	mux.Handle("/debug/pprof/symbol", protect(pprof.Symbol))
+	mux.Handle("/debug/pprof/trace", protect(pprof.Trace))
+	mux.Handle("GET /debug/locks", protect(s.locksHandler))
+}
+
+// locksHandler reports the number of goroutines, contention on the server
+// lock and on each game's lock, and how long ago the game loop last ran.
+func (s *Server) locksHandler(w http.ResponseWriter, r *http.Request) {
+	type gameLocks struct {
+		Clients int       `json:"clients"`
+		Lock    lockStats `json:"lock"`
+	}
+	report := struct {
+		Goroutines      int                  `json:"goroutines"`
+		LoopIdleSeconds float64              `json:"loopIdleSeconds"`
+		ServerLock      lockStats            `json:"serverLock"`
+		Games           map[string]gameLocks `json:"games"`
+	}{
+		Goroutines: runtime.NumGoroutine(),
+		Games:      make(map[string]gameLocks),
+	}
+	if last := s.loopActive.Load(); last != 0 {
+		report.LoopIdleSeconds = time.Since(time.Unix(0, last)).Seconds()
+	}
+
+	// Read the server lock's counters before taking it, so this request's
+	// own acquisition is not counted.
+	report.ServerLock = s.mu.stats()
+	s.mu.Lock()
+	for gameID, game := range s.games {
+		report.Games[gameID] = gameLocks{Lock: game.mu.stats()}
+	}
+	for client := range s.clients {
+		if g, ok := report.Games[client.gameID]; ok {
+			g.Clients++
+			report.Games[client.gameID] = g
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
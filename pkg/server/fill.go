@@ -0,0 +1,99 @@
+package server
+
+import (
+	"fmt"
+
+	"GameOfLife/pkg/engine"
+	"GameOfLife/pkg/patterns"
+)
+
+// InitialFill describes how the board of a freshly created game is seeded.
+type InitialFill struct {
+	Mode    string // empty, random, c2, c4, d8, pattern or rle
+	Density int    // percentage of live cells for random and symmetric soups
+	Pattern string // library pattern name for the "pattern" mode
+	RLE     string // run-length encoded pattern for the "rle" mode
+}
+
+// defaultFill keeps the behaviour of the original server: a 20% random soup.
+var defaultFill = InitialFill{Mode: "random", Density: 20}
+
+// parseInitialFill reads the optional fill fields of an "init" message.
+func parseInitialFill(msg map[string]interface{}) (InitialFill, error) {
+	fill := defaultFill
+	if v, ok := msg["fill"]; ok {
+		mode, ok := v.(string)
+		if !ok {
+			return fill, fmt.Errorf("fill must be a string")
+		}
+		fill.Mode = mode
+	}
+	if v, ok := msg["density"]; ok {
+		density, ok := v.(float64)
+		if !ok || density < 0 || density > 100 {
+			return fill, fmt.Errorf("density must be a number between 0 and 100")
+		}
+		fill.Density = int(density)
+	}
+	switch fill.Mode {
+	case "empty", "random", "c2", "c4", "d8":
+	case "pattern":
+		pattern, ok := msg["pattern"].(string)
+		if !ok || pattern == "" {
+			return fill, fmt.Errorf("fill %q requires a pattern name", fill.Mode)
+		}
+		fill.Pattern = pattern
+	case "rle":
+		rle, ok := msg["rle"].(string)
+		if !ok || rle == "" {
+			return fill, fmt.Errorf("fill %q requires an rle string", fill.Mode)
+		}
+		if len(rle) > patterns.MaxRLELength {
+			return fill, fmt.Errorf("rle is longer than %d bytes", patterns.MaxRLELength)
+		}
+		fill.RLE = rle
+	default:
+		return fill, fmt.Errorf("unknown fill %q", fill.Mode)
+	}
+	return fill, nil
+}
+
+// applyFill seeds the board according to f. It is only called on games that
+// have not been published yet, so it does not take g.mu.
+func (g *Game) applyFill(f InitialFill) error {
+	switch f.Mode {
+	case "empty":
+	case "random":
+		g.Randomize(f.Density)
+	case engine.C2, engine.C4, engine.D8:
+		g.SymmetricSoup(f.Mode, f.Density)
+	case "pattern":
+		return g.applyPattern(f.Pattern)
+	case "rle":
+		cells, err := patterns.ParseRLE(f.RLE)
+		if err != nil {
+			return err
+		}
+		return g.Stamp(cells)
+	default:
+		return fmt.Errorf("unknown fill %q", f.Mode)
+	}
+	return nil
+}
+
+// applyPattern places the named library pattern on the board. The caller
+// must hold g.mu.
+func (g *Game) applyPattern(name string) error {
+	p, ok := patterns.Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown pattern %q", name)
+	}
+	cells, err := p.Place(g.Width, g.Height)
+	if err != nil {
+		return err
+	}
+	for _, c := range cells {
+		g.Birth(c.X, c.Y)
+	}
+	return nil
+}
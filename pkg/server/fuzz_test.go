@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// fuzzOwnerToken is the owner token of the games FuzzHandleClientMessage
+// sets up, so inputs can act as their owner.
+const fuzzOwnerToken = "owner"
+
+// newSink serves WebSocket connections that discard everything sent to them.
+// Handlers under fuzzing write their replies to such a connection.
+func newSink(t testing.TB) *httptest.Server {
+	t.Helper()
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(sink.Close)
+	return sink
+}
+
+// FuzzHandleClientMessage feeds one message to handleClientMessage from a
+// client that created a territory game "t1" and then a classic game "c1",
+// which it is playing in. Inputs name either game, or another to create
+// one; the owner token of both is fuzzOwnerToken.
+func FuzzHandleClientMessage(f *testing.F) {
+	seeds := []string{
+		`{"type":"init","gameID":"g2","width":40,"height":30,"cellSize":5}`,
+		`{"type":"init","gameID":"g2","width":40,"height":30,"cellSize":5,"fill":"pattern","pattern":"glider"}`,
+		`{"type":"init","gameID":"g2","width":40,"height":30,"cellSize":5,"fill":"rle","rle":"bo$2bo$3o!"}`,
+		`{"type":"init","gameID":"g2","width":40,"height":30,"cellSize":5,"fill":"d8","density":30}`,
+		`{"type":"init","gameID":"g2","width":40,"height":30,"cellSize":5,"mode":"territory","budget":5}`,
+		`{"type":"join","gameID":"c1","token":"owner","name":"Ada"}`,
+		`{"type":"birth","gameID":"c1","x":5,"y":5}`,
+		`{"type":"birth","gameID":"t1","token":"owner","x":5,"y":5}`,
+		`{"type":"stop","gameID":"c1"}`,
+		`{"type":"resume","gameID":"c1"}`,
+		`{"type":"setBackgroundColor","gameID":"c1","color":"red"}`,
+		`{"type":"clear","gameID":"c1"}`,
+		`{"type":"randomBirth","gameID":"c1","percentage":20}`,
+		`{"type":"pattern","gameID":"c1","pattern":"gosper_glider_gun"}`,
+		`{"type":"setName","gameID":"c1","name":"Grace"}`,
+		`{"type":"startRound","gameID":"t1","token":"owner"}`,
+		`{"type":"ready","gameID":"t1","token":"owner"}`,
+		`{"type":"cursor","gameID":"c1","x":3,"y":4}`,
+		`{"type":"selection","gameID":"c1","x":3,"y":4,"width":5,"height":2}`,
+		`{"type":"chat","gameID":"c1","text":"hello"}`,
+		`{"type":"resize","gameID":"c1","width":40,"height":30,"anchor":"top-left"}`,
+		`{"type":"viewport","gameID":"c1","x":2,"y":3,"width":10,"height":8,"zoom":3}`,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+	sink := newSink(f)
+	url := "ws" + strings.TrimPrefix(sink.URL, "http")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var msg map[string]interface{}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return // the read loop drops the connection on invalid JSON
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		s := New(DefaultConfig())
+		client := &Client{conn: conn, limiter: newRateLimiter()}
+		s.mu.Lock()
+		s.assignPlayerID(client)
+		s.clients[client] = true
+		s.mu.Unlock()
+		s.handleClientMessage(client, map[string]interface{}{"type": protocol.TypeInit, "gameID": "t1", "width": 30.0, "height": 20.0, "cellSize": 5.0, "mode": "territory"})
+		s.handleClientMessage(client, map[string]interface{}{"type": protocol.TypeInit, "gameID": "c1", "width": 30.0, "height": 20.0, "cellSize": 5.0})
+		for _, game := range s.games {
+			game.ownerToken = fuzzOwnerToken
+		}
+		defer s.stopRoundTimers()
+
+		s.handleClientMessage(client, msg)
+
+		// A handler must release every lock, even when it rejects msg.
+		if !s.mu.TryLock() {
+			t.Fatal("server lock still held")
+		}
+		s.mu.Unlock()
+		for id, game := range s.games {
+			if !game.mu.TryLock() {
+				t.Fatalf("lock of game %s still held", id)
+			}
+			game.mu.Unlock()
+		}
+	})
+}
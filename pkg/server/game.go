@@ -0,0 +1,59 @@
+package server
+
+import (
+	"time"
+
+	"GameOfLife/pkg/engine"
+)
+
+// Game is a game hosted by the server: its board and the settings, round
+// and chat its players share.
+type Game struct {
+	engine.GameState
+	CellSize        int
+	Color           string
+	BackgroundColor string
+	Interval        int64 // shortest time between generations, in nanoseconds
+	Stopped         bool
+	Mode            string
+	Round           *Round // round state machine of territory games
+	mu              trackedMutex
+
+	lastGeneration time.Time // when the game last advanced, guarded by mu
+
+	chat        []chatMessage // recent chat, oldest first, guarded by mu
+	ownerToken  string
+	inviteToken string
+}
+
+// newGame returns a game with an empty board. Use applyFill to seed it.
+func newGame(width, height, cellSize int, color, bgColor string, interval int64) *Game {
+	return &Game{
+		GameState:       *engine.New(width, height),
+		CellSize:        cellSize,
+		Color:           color,
+		BackgroundColor: bgColor,
+		Interval:        interval,
+		Mode:            modeClassic,
+	}
+}
+
+// update advances the board by a generation and stops the game once every
+// cell has died. It returns the number of live cells. The caller must hold
+// g.mu.
+func (g *Game) update() int {
+	liveCells := g.Update()
+	g.lastGeneration = time.Now()
+	if liveCells == 0 {
+		g.Stopped = true
+	}
+	return liveCells
+}
+
+// due reports whether the game loop should advance the game: it is running
+// and its interval has passed since the last generation.
+func (g *Game) due(now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return !g.Stopped && now.Sub(g.lastGeneration) >= time.Duration(g.Interval)
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// Readiness states reported by /readyz. Only stateReady accepts traffic.
+const (
+	stateStarting     = "starting"
+	stateRestoring    = "restoring games"
+	stateReady        = "ready"
+	stateShuttingDown = "shutting down"
+)
+
+// setReadiness records the server's readiness state and logs the change.
+func (s *Server) setReadiness(state string) {
+	if s.readiness.Swap(state) != state {
+		slog.Info("readiness changed", "state", state)
+	}
+}
+
+// healthzHandler reports that the process is alive and serving HTTP.
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// readyzHandler reports whether the server should receive traffic: not
+// while saved games are being restored, nor once a shutdown has begun.
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	state := s.readiness.Load().(string)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if state != stateReady {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	fmt.Fprintln(w, state)
+}
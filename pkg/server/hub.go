@@ -0,0 +1,607 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"GameOfLife/pkg/engine"
+	"GameOfLife/pkg/protocol"
+)
+
+type Client struct {
+	conn   *websocket.Conn
+	gameID string
+	role   string
+	id     string
+	name   string
+	seq    int
+	team   int
+
+	cursorAt    time.Time // last relayed cursor, for rate limiting
+	selectionAt time.Time // last relayed selection
+	chatAt      time.Time // last chat message
+
+	viewport protocol.Viewport // part of the board the client receives, zero for all of it
+
+	limiter *rateLimiter // used only by the connection's read loop
+}
+
+// gameLoop advances every running game whose interval has passed, pausing
+// cfg.Tick after each, until ctx is cancelled.
+func (s *Server) gameLoop(ctx context.Context) {
+	wait := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(s.cfg.Tick):
+			return true
+		}
+	}
+	for {
+		s.mu.Lock()
+		gameIDs := make([]string, 0, len(s.games))
+		for gameID := range s.games {
+			gameIDs = append(gameIDs, gameID)
+		}
+		s.mu.Unlock()
+		if len(gameIDs) == 0 && !wait() {
+			return
+		}
+		passStart, generations := time.Now(), 0
+
+		for _, gameID := range gameIDs {
+			s.loopActive.Store(time.Now().UnixNano())
+			s.mu.Lock()
+			game, exists := s.games[gameID]
+			s.mu.Unlock()
+			if !exists {
+				continue
+			}
+			if game.due(time.Now()) {
+				s.advanceGame(game, gameID)
+				generations++
+			}
+			if !wait() {
+				return
+			}
+		}
+		if len(gameIDs) > 0 {
+			s.metrics.generationsPerSecond.set(float64(generations) / time.Since(passStart).Seconds())
+		}
+	}
+}
+
+// advanceGame computes the next generation of a game, moves its territory
+// round on and sends the result to its players.
+func (s *Server) advanceGame(game *Game, gameID string) {
+	start := time.Now()
+	game.mu.Lock()
+	liveCells := game.update()
+	stopped := game.Stopped
+	game.mu.Unlock()
+	s.metrics.updateDuration.observe(time.Since(start))
+	s.logTick("game updated", "gameID", gameID, "liveCells", liveCells, "stopped", stopped)
+	s.metrics.generationsTotal.add(1)
+	if game.Round != nil {
+		s.advanceRound(game, gameID)
+	}
+	s.broadcastGameState(game, gameID)
+	if game.Mode != modeClassic {
+		s.broadcastScores(game, gameID)
+	}
+	s.logTick("state broadcast", "gameID", gameID)
+}
+
+func (s *Server) broadcastGameState(game *Game, gameID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Clients sharing a viewport, or watching the whole board, share one
+	// encoded state.
+	viewports := make(map[protocol.Viewport]bool)
+	for client := range s.clients {
+		if client.gameID == gameID {
+			viewports[client.viewport] = true
+		}
+	}
+	states := game.boardStates(viewports)
+	start := time.Now()
+	encoded := make(map[protocol.Viewport][]byte, len(states))
+	for v, state := range states {
+		data, err := json.Marshal(state)
+		if err != nil {
+			slog.Error("encoding game state failed", "gameID", gameID, "err", err)
+			return
+		}
+		encoded[v] = data
+	}
+	for client := range s.clients {
+		if client.gameID == gameID {
+			data := encoded[client.viewport]
+			err := client.conn.WriteMessage(websocket.TextMessage, data)
+			if err != nil {
+				slog.Warn("sending to client failed", "gameID", gameID, "clientID", client.id, "err", err)
+				s.metrics.droppedMessages.add("write_error", 1)
+				client.conn.Close()
+				delete(s.clients, client)
+			} else {
+				s.metrics.broadcastBytes.add(float64(len(data)))
+				s.logTick("game state sent", "gameID", gameID, "clientID", client.id)
+			}
+		}
+	}
+	s.metrics.broadcastDuration.observe(time.Since(start))
+}
+
+// sendGameState sends the board state to one client, cut to its viewport.
+func (s *Server) sendGameState(client *Client, game *Game) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := game.boardStates(map[protocol.Viewport]bool{client.viewport: true})[client.viewport]
+	if err := client.conn.WriteJSON(state); err != nil {
+		slog.Warn("sending game state failed", "gameID", client.gameID, "clientID", client.id, "err", err)
+		return
+	}
+	s.logTick("game state sent", "gameID", client.gameID, "clientID", client.id)
+}
+
+// broadcastMessage sends msg to every client in a game. The caller must hold
+// s.mu.
+func (s *Server) broadcastMessage(gameID string, msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("encoding message failed", "gameID", gameID, "err", err)
+		return
+	}
+	for client := range s.clients {
+		if client.gameID == gameID {
+			if err := client.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				slog.Warn("sending to client failed", "gameID", gameID, "clientID", client.id, "err", err)
+				s.metrics.droppedMessages.add("write_error", 1)
+				client.conn.Close()
+				delete(s.clients, client)
+			} else {
+				s.metrics.broadcastBytes.add(float64(len(data)))
+			}
+		}
+	}
+}
+
+// sendError replies to a single client with a message describing why its
+// request was rejected.
+func (s *Server) sendError(client *Client, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := client.conn.WriteJSON(map[string]string{"type": protocol.TypeError, "message": message})
+	if err != nil {
+		slog.Warn("sending error reply failed", "gameID", client.gameID, "clientID", client.id, "err", err)
+	}
+}
+
+// closeClient disconnects a client with a close frame giving the reason.
+func (s *Server) closeClient(client *Client, code int, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deadline := time.Now().Add(time.Second)
+	if err := client.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline); err != nil {
+		slog.Warn("sending close frame failed", "clientID", client.id, "err", err)
+	}
+	client.conn.Close()
+}
+
+func (s *Server) wsHandler(w http.ResponseWriter, r *http.Request) {
+	// A game created now would be replaced by its saved copy.
+	if s.readiness.Load() == stateRestoring {
+		http.Error(w, "restoring games, try again shortly", http.StatusServiceUnavailable)
+		return
+	}
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Warn("websocket upgrade failed", "remoteAddr", r.RemoteAddr, "err", err)
+		return
+	}
+
+	conn.SetReadLimit(s.cfg.MaxMessageSize)
+	client := &Client{conn: conn, gameID: "", limiter: newRateLimiter()}
+	s.mu.Lock()
+	s.assignPlayerID(client)
+	s.clients[client] = true
+	s.mu.Unlock()
+	slog.Info("client connected", "clientID", client.id, "remoteAddr", r.RemoteAddr)
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		gameID := client.gameID
+		s.mu.Unlock()
+		conn.Close()
+		s.broadcastPresence(gameID)
+		slog.Info("client disconnected", "clientID", client.id, "gameID", gameID)
+	}()
+
+	for {
+		var msg map[string]interface{}
+		err := conn.ReadJSON(&msg)
+		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				s.metrics.droppedMessages.add("too_large", 1)
+			}
+			slog.Info("websocket read ended", "clientID", client.id, "err", err)
+			return
+		}
+		slog.Debug("message received", "clientID", client.id, "type", msg["type"], "gameID", msg["gameID"])
+		if s.limitMessage(client, msg) {
+			s.handleClientMessage(client, msg)
+		}
+	}
+}
+
+// maxCellSize bounds the cell size of a new game, which sets the scale of
+// its image exports.
+const maxCellSize = 50
+
+// parseBoardSize reads the dimensions of an "init" message that creates a
+// game and checks them against the configured limits.
+func (s *Server) parseBoardSize(msg map[string]interface{}) (width, height, cellSize int, err error) {
+	for _, f := range []struct {
+		name string
+		v    *int
+	}{{"width", &width}, {"height", &height}, {"cellSize", &cellSize}} {
+		n, ok := msg[f.name].(float64)
+		if !ok {
+			return 0, 0, 0, fmt.Errorf("init needs a numeric %s", f.name)
+		}
+		*f.v = int(n)
+	}
+	if err := s.checkBoardSize(width, height); err != nil {
+		return 0, 0, 0, err
+	}
+	if cellSize < 1 || cellSize > maxCellSize {
+		return 0, 0, 0, fmt.Errorf("cellSize must be between 1 and %d", maxCellSize)
+	}
+	return width, height, cellSize, nil
+}
+
+// checkBoardSize checks the size of a new or resized board against the
+// configured limits.
+func (s *Server) checkBoardSize(width, height int) error {
+	switch {
+	case width < engine.MinSize || height < engine.MinSize:
+		return fmt.Errorf("board %dx%d is smaller than the %dx%d minimum", width, height, engine.MinSize, engine.MinSize)
+	case width > s.cfg.MaxWidth || height > s.cfg.MaxHeight:
+		return fmt.Errorf("board %dx%d is larger than the %dx%d limit", width, height, s.cfg.MaxWidth, s.cfg.MaxHeight)
+	}
+	return nil
+}
+
+// messageFields lists the fields each message type must carry, and whether
+// each is a number or a string. Messages without them are refused before
+// any handler runs.
+var messageFields = map[string][]struct {
+	name    string
+	numeric bool
+}{
+	protocol.TypeBirth:              {{"x", true}, {"y", true}},
+	protocol.TypeSetBackgroundColor: {{"color", false}},
+	protocol.TypeRandomBirth:        {{"percentage", true}},
+	protocol.TypePattern:            {{"pattern", false}},
+	protocol.TypeResize:             {{"width", true}, {"height", true}},
+}
+
+// checkFields reports the first field msg is missing, see messageFields.
+func checkFields(msg map[string]interface{}) error {
+	msgType, _ := msg["type"].(string)
+	for _, f := range messageFields[msgType] {
+		if f.numeric {
+			if _, ok := msg[f.name].(float64); !ok {
+				return fmt.Errorf("%s needs a numeric %s", msgType, f.name)
+			}
+		} else if _, ok := msg[f.name].(string); !ok {
+			return fmt.Errorf("%s needs a string %s", msgType, f.name)
+		}
+	}
+	return nil
+}
+
+// parseResize reads the new size and anchor of a resize message, which
+// only the owner may send. The anchor defaults to the centre.
+func (s *Server) parseResize(client *Client, msg map[string]interface{}) (width, height int, anchor string, err error) {
+	if client.role != roleOwner {
+		return 0, 0, "", fmt.Errorf("only the owner can resize the board")
+	}
+	width, height = int(msg["width"].(float64)), int(msg["height"].(float64))
+	if err := s.checkBoardSize(width, height); err != nil {
+		return 0, 0, "", err
+	}
+	anchor = engine.AnchorCenter
+	if v, ok := msg["anchor"]; ok {
+		if anchor, ok = v.(string); !ok || !engine.ValidAnchor(anchor) {
+			return 0, 0, "", fmt.Errorf("anchor must be one of %s", strings.Join(engine.Anchors, ", "))
+		}
+	}
+	return width, height, anchor, nil
+}
+
+// removeGame forgets a game and stops its round timer, which would otherwise
+// fire on a game nobody can reach. The caller must hold s.mu.
+func (s *Server) removeGame(gameID string) {
+	game, exists := s.games[gameID]
+	if !exists {
+		return
+	}
+	delete(s.games, gameID)
+	game.mu.Lock()
+	if game.Round != nil {
+		game.Round.stopTimer()
+	}
+	game.mu.Unlock()
+}
+
+// resizeGame applies a resize message and sends everyone the resized board.
+func (s *Server) resizeGame(client *Client, game *Game, gameID string, msg map[string]interface{}) error {
+	width, height, anchor, err := s.parseResize(client, msg)
+	if err != nil {
+		return err
+	}
+	game.mu.Lock()
+	oldWidth, oldHeight := game.Width, game.Height
+	err = game.Resize(width, height, anchor)
+	game.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	slog.Info("board resized", "gameID", gameID, "clientID", client.id,
+		"from", fmt.Sprintf("%dx%d", oldWidth, oldHeight), "to", fmt.Sprintf("%dx%d", width, height), "anchor", anchor)
+	s.broadcastGameState(game, gameID)
+	return nil
+}
+
+func (s *Server) handleClientMessage(client *Client, msg map[string]interface{}) {
+	msgType, _ := msg["type"].(string)
+	s.metrics.countMessage(msgType)
+	gameID, ok := msg["gameID"].(string)
+	if !ok {
+		slog.Warn("message without gameID", "clientID", client.id, "type", msgType)
+		return
+	}
+
+	joined := false
+	s.mu.Lock()
+	previousGameID := client.gameID
+	game, exists := s.games[gameID]
+	if !exists && msg["type"] == protocol.TypeInit {
+		width, height, cellSize, err := s.parseBoardSize(msg)
+		var fill InitialFill
+		if err == nil {
+			fill, err = parseInitialFill(msg)
+		}
+		if err == nil {
+			game = newGame(width, height, cellSize, s.cfg.DefaultColor, s.cfg.DefaultBackground, int64(s.cfg.DefaultInterval))
+			err = game.applyFill(fill)
+		}
+		mode := modeClassic
+		if err == nil {
+			mode, err = parseMode(msg)
+		}
+		if err != nil {
+			s.mu.Unlock()
+			slog.Info("init rejected", "gameID", gameID, "clientID", client.id, "err", err)
+			s.sendError(client, err.Error())
+			return
+		}
+		game.enableTeams(mode)
+		if mode == modeTerritory {
+			round, err := parseRound(msg)
+			if err != nil {
+				s.mu.Unlock()
+				slog.Info("init rejected", "gameID", gameID, "clientID", client.id, "err", err)
+				s.sendError(client, err.Error())
+				return
+			}
+			game.Round = round
+			game.Stopped = true
+		}
+		game.ownerToken = newToken()
+		game.inviteToken = newToken()
+		s.games[gameID] = game
+		client.gameID = gameID
+		client.role = roleOwner
+		s.assignTeam(client, game)
+		setName(client, msg)
+		joined = true
+		slog.Info("game created", "gameID", gameID, "clientID", client.id, "mode", mode, "width", width, "height", height, "fill", fill.Mode)
+	} else if exists {
+		// Roles are decided when a client joins or switches games.
+		joined = client.gameID != gameID || msg["type"] == protocol.TypeInit || msg["type"] == protocol.TypeJoin
+		if client.gameID != gameID {
+			client.viewport = protocol.Viewport{}
+		}
+		client.gameID = gameID
+		if joined {
+			token, _ := msg["token"].(string)
+			client.role = game.roleFor(token)
+			s.assignTeam(client, game)
+			setName(client, msg)
+			slog.Info("client joined game", "gameID", gameID, "clientID", client.id)
+		}
+	}
+	s.mu.Unlock()
+
+	if !exists && msg["type"] != protocol.TypeInit {
+		slog.Info("game not found", "gameID", gameID, "clientID", client.id, "type", msgType)
+		s.sendError(client, "game "+gameID+" not found")
+		return
+	}
+	if joined {
+		s.sendRole(client, game)
+		slog.Info("role assigned", "gameID", gameID, "clientID", client.id, "role", client.role)
+		if previousGameID != gameID {
+			s.broadcastPresence(previousGameID)
+		}
+		s.broadcastPresence(gameID)
+		if game.Round != nil {
+			s.broadcastRound(game, gameID)
+		}
+		s.sendChatHistory(client, game)
+	}
+	if mutatingMessages[msgType] {
+		if !client.canEdit() {
+			slog.Info("message rejected for role", "gameID", gameID, "clientID", client.id, "type", msgType, "role", client.role)
+			s.sendError(client, "spectators cannot send "+msgType)
+			return
+		}
+		if err := checkFields(msg); err != nil {
+			slog.Info("message rejected", "gameID", gameID, "clientID", client.id, "type", msgType, "err", err)
+			s.sendError(client, err.Error())
+			return
+		}
+		if game.Round != nil {
+			handled, err := s.handleTerritoryMessage(client, game, gameID, msg)
+			if err != nil {
+				slog.Info("territory message rejected", "gameID", gameID, "clientID", client.id, "type", msgType, "err", err)
+				s.sendError(client, err.Error())
+				return
+			}
+			if handled {
+				s.broadcastAction(client, gameID, msg)
+				return
+			}
+		} else if msgType == protocol.TypeStartRound || msgType == protocol.TypeReady {
+			slog.Info("message rejected outside territory mode", "gameID", gameID, "clientID", client.id, "type", msgType)
+			s.sendError(client, msgType+" is only allowed in territory games")
+			return
+		}
+		if msgType == protocol.TypeResize {
+			if err := s.resizeGame(client, game, gameID, msg); err != nil {
+				slog.Info("resize rejected", "gameID", gameID, "clientID", client.id, "err", err)
+				s.sendError(client, err.Error())
+				return
+			}
+			s.broadcastAction(client, gameID, msg)
+			return
+		}
+		defer s.broadcastAction(client, gameID, msg)
+	}
+
+	switch msg["type"] {
+	case protocol.TypeInit, protocol.TypeJoin:
+		s.broadcastGameState(game, gameID)
+	case protocol.TypeCursor, protocol.TypeSelection:
+		if err := s.relayCursor(client, game, gameID, msg); err != nil {
+			s.sendError(client, err.Error())
+		}
+	case protocol.TypeViewport:
+		viewport, err := parseViewport(msg)
+		if err != nil {
+			s.sendError(client, err.Error())
+			return
+		}
+		s.mu.Lock()
+		client.viewport = viewport
+		s.mu.Unlock()
+		slog.Debug("viewport changed", "gameID", gameID, "clientID", client.id, "viewport", viewport)
+		s.sendGameState(client, game)
+	case protocol.TypeChat:
+		if err := s.sendChat(client, game, gameID, msg); err != nil {
+			slog.Info("chat rejected", "gameID", gameID, "clientID", client.id, "err", err)
+			s.sendError(client, err.Error())
+		}
+	case protocol.TypeSetName:
+		s.mu.Lock()
+		setName(client, msg)
+		s.mu.Unlock()
+		s.broadcastPresence(gameID)
+	case protocol.TypeBirth:
+		x := int(msg["x"].(float64))
+		y := int(msg["y"].(float64))
+		game.mu.Lock()
+		if game.Birth(x, y) {
+			slog.Debug("cell born", "gameID", gameID, "x", x, "y", y)
+		} else {
+			slog.Debug("birth failed, out of bounds or already alive", "gameID", gameID, "x", x, "y", y)
+		}
+		game.ClaimUnowned(client.team)
+		game.mu.Unlock()
+		s.broadcastGameState(game, gameID)
+	case protocol.TypeStop:
+		game.mu.Lock()
+		game.Stopped = true
+		game.mu.Unlock()
+		slog.Info("game stopped", "gameID", gameID, "clientID", client.id)
+		s.broadcastGameState(game, gameID)
+	case protocol.TypeResume:
+		game.mu.Lock()
+		game.Stopped = false
+		game.mu.Unlock()
+		slog.Info("game resumed", "gameID", gameID, "clientID", client.id)
+		s.broadcastGameState(game, gameID)
+	case protocol.TypeSetBackgroundColor:
+		color := msg["color"].(string)
+		game.mu.Lock()
+		game.BackgroundColor = color
+		game.mu.Unlock()
+		slog.Info("background colour set", "gameID", gameID, "clientID", client.id, "color", color)
+		s.broadcastGameState(game, gameID)
+	case protocol.TypeClear:
+		game.mu.Lock()
+		game.Clear()
+		game.mu.Unlock()
+		slog.Info("board cleared", "gameID", gameID, "clientID", client.id)
+		s.broadcastGameState(game, gameID)
+	case protocol.TypeRandomBirth:
+		percentage := min(max(int(msg["percentage"].(float64)), 0), 100)
+		slog.Debug("random birth starting", "gameID", gameID, "clientID", client.id, "percentage", percentage)
+		game.mu.Lock()
+		defer func() {
+			game.ClaimUnowned(client.team)
+			game.mu.Unlock()
+			if r := recover(); r != nil {
+				slog.Error("panic in randomBirth", "gameID", gameID, "clientID", client.id, "panic", r)
+			}
+			slog.Info("random birth applied", "gameID", gameID, "clientID", client.id, "percentage", percentage)
+			s.broadcastGameState(game, gameID)
+		}()
+		game.Randomize(percentage)
+	case protocol.TypePattern:
+		pattern := msg["pattern"].(string)
+		slog.Debug("pattern starting", "gameID", gameID, "clientID", client.id, "pattern", pattern)
+		game.mu.Lock()
+		defer func() {
+			game.ClaimUnowned(client.team)
+			game.mu.Unlock()
+			if r := recover(); r != nil {
+				slog.Error("panic in pattern", "gameID", gameID, "clientID", client.id, "pattern", pattern, "panic", r)
+			}
+			slog.Info("pattern applied", "gameID", gameID, "clientID", client.id, "pattern", pattern)
+			s.broadcastGameState(game, gameID)
+		}()
+		if err := game.applyPattern(pattern); err != nil {
+			slog.Info("pattern not applied", "gameID", gameID, "clientID", client.id, "pattern", pattern, "err", err)
+		}
+	}
+}
+
+// Serve static files and index.html for SPA routes
+func (s *Server) serveHandler(w http.ResponseWriter, r *http.Request) {
+	// Clean the requested path
+	p := path.Clean(r.URL.Path)
+	if p == "/" || strings.HasPrefix(p, "/game_") {
+		// Serve index.html for root and game IDs
+		slog.Debug("serving index.html", "path", p)
+		http.ServeFile(w, r, filepath.Join(s.cfg.AssetDir, "index.html"))
+		return
+	}
+
+	// Serve static files from assets directory
+	fs := http.FileServer(http.Dir(s.cfg.AssetDir))
+	fs.ServeHTTP(w, r)
+}
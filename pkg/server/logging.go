@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+// logLevelNames maps the log-level setting to slog levels.
+var logLevelNames = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// SetupLogging makes the default slog logger write to w at the configured
+// level, as text or JSON.
+func SetupLogging(w io.Writer, c Config) {
+	opts := &slog.HandlerOptions{Level: logLevelNames[c.LogLevel]}
+	var handler slog.Handler = slog.NewTextHandler(w, opts)
+	if c.LogFormat == "json" {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// logTick logs an event that happens every generation or for every client
+// on every generation. Such events are logged at debug level, and only one
+// in cfg.LogSample of each is kept so debug logs stay readable at scale.
+func (s *Server) logTick(msg string, args ...any) {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	v, _ := s.tickSamples.LoadOrStore(msg, new(atomic.Uint64))
+	if (v.(*atomic.Uint64).Add(1)-1)%uint64(s.cfg.LogSample) != 0 {
+		return
+	}
+	slog.Debug(msg, append(args, "sampleRate", s.cfg.LogSample)...)
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// counterVec is a Prometheus counter with one label.
+type counterVec struct {
+	name, help, label string
+	mu                sync.Mutex
+	values            map[string]float64
+}
+
+func newCounterVec(name, help, label string) *counterVec {
+	return &counterVec{name: name, help: help, label: label, values: make(map[string]float64)}
+}
+
+func (c *counterVec) add(labelValue string, n float64) {
+	c.mu.Lock()
+	c.values[labelValue] += n
+	c.mu.Unlock()
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, v := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s{%s=%s} %s\n", c.name, c.label, quoteLabel(v), formatFloat(c.values[v]))
+	}
+}
+
+// counter is a Prometheus counter without labels.
+type counter struct {
+	name, help string
+	mu         sync.Mutex
+	value      float64
+}
+
+func (c *counter) add(n float64) {
+	c.mu.Lock()
+	c.value += n
+	c.mu.Unlock()
+}
+
+func (c *counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %s\n", c.name, c.help, c.name, c.name, formatFloat(c.value))
+}
+
+// gauge is a Prometheus gauge without labels.
+type gauge struct {
+	name, help string
+	mu         sync.Mutex
+	value      float64
+}
+
+func (g *gauge) set(v float64) {
+	g.mu.Lock()
+	g.value = v
+	g.mu.Unlock()
+}
+
+func (g *gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, formatFloat(g.value))
+}
+
+// histogram is a Prometheus histogram of durations in seconds.
+type histogram struct {
+	name, help string
+	buckets    []float64 // upper bounds, ascending
+	mu         sync.Mutex
+	counts     []uint64 // per bucket, not cumulative
+	sum        float64
+	count      uint64
+}
+
+func newHistogram(name, help string, buckets []float64) *histogram {
+	return &histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	v := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	var cumulative uint64
+	for i, le := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(le), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %s\n%s_count %d\n", h.name, h.count, h.name, formatFloat(h.sum), h.name, h.count)
+}
+
+// maxGameSeries bounds the games given their own gol_clients_connected
+// series, as game IDs are chosen by clients.
+const maxGameSeries = 100
+
+// latencyBuckets cover 100µs to 2.5s.
+var latencyBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// metrics are a server's metrics, exposed on /metrics. Each Server has its
+// own, so servers sharing a process do not count each other's work.
+type metrics struct {
+	generationsTotal     *counter
+	generationsPerSecond *gauge
+	updateDuration       *histogram
+	broadcastDuration    *histogram
+	broadcastBytes       *counter
+	messagesTotal        *counterVec
+	droppedMessages      *counterVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		generationsTotal:     &counter{name: "gol_generations_total", help: "Generations computed across all games."},
+		generationsPerSecond: &gauge{name: "gol_generations_per_second", help: "Generations per second over the last pass of the game loop."},
+		updateDuration:       newHistogram("gol_update_duration_seconds", "Time taken by Game.Update.", latencyBuckets),
+		broadcastDuration:    newHistogram("gol_broadcast_duration_seconds", "Time taken to send a board state to every client in a game.", latencyBuckets),
+		broadcastBytes:       &counter{name: "gol_broadcast_bytes_total", help: "Bytes of broadcast messages written to clients."},
+		messagesTotal:        newCounterVec("gol_messages_total", "Client messages handled, by type.", "type"),
+		droppedMessages:      newCounterVec("gol_dropped_messages_total", "Messages dropped, by reason.", "reason"),
+	}
+}
+
+// countMessage records a handled client message. Types the server does not
+// know are counted together, so clients cannot create label values at will.
+func (m *metrics) countMessage(msgType string) {
+	if _, known := messageLimits[msgType]; !known {
+		msgType = "unknown"
+	}
+	m.messagesTotal.add(msgType, 1)
+}
+
+// metricsHandler serves the metrics in the Prometheus text format.
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	s.mu.Lock()
+	activeGames, connected := len(s.games), len(s.clients)
+	perGame := make(map[string]float64, len(s.games))
+	for gameID := range s.games {
+		perGame[gameID] = 0
+	}
+	for client := range s.clients {
+		if _, exists := perGame[client.gameID]; exists {
+			perGame[client.gameID]++
+		}
+	}
+	s.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP gol_games_active Games held by the server.\n# TYPE gol_games_active gauge\ngol_games_active %d\n", activeGames)
+	fmt.Fprintf(w, "# HELP gol_clients_connected Connected clients in total and by game, beyond the %d busiest games under game=\"other\".\n# TYPE gol_clients_connected gauge\n", maxGameSeries)
+	fmt.Fprintf(w, "gol_clients_connected %d\n", connected)
+	perGame = busiest(perGame, maxGameSeries)
+	for _, gameID := range sortedKeys(perGame) {
+		fmt.Fprintf(w, "gol_clients_connected{game=%s} %s\n", quoteLabel(gameID), formatFloat(perGame[gameID]))
+	}
+	s.metrics.generationsTotal.write(w)
+	s.metrics.generationsPerSecond.write(w)
+	s.metrics.updateDuration.write(w)
+	s.metrics.broadcastDuration.write(w)
+	s.metrics.broadcastBytes.write(w)
+	s.metrics.messagesTotal.write(w)
+	s.metrics.droppedMessages.write(w)
+}
+
+// busiest keeps the n largest values of m, breaking ties by key, and sums
+// the rest under "other".
+func busiest(m map[string]float64, n int) map[string]float64 {
+	if len(m) <= n {
+		return m
+	}
+	keys := sortedKeys(m)
+	sort.SliceStable(keys, func(i, j int) bool { return m[keys[i]] > m[keys[j]] })
+	kept := make(map[string]float64, n+1)
+	for i, k := range keys {
+		if i < n {
+			kept[k] += m[k]
+		} else {
+			kept["other"] += m[k]
+		}
+	}
+	return kept
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quoteLabel quotes a label value, escaping backslashes, quotes and
+// newlines as the text format requires.
+func quoteLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
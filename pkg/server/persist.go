@@ -0,0 +1,177 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"GameOfLife/pkg/engine"
+)
+
+// savedGame is the on-disk form of a GameState, including the tokens and
+// chat that are not part of any broadcast.
+type savedGame struct {
+	Board           [][]uint8
+	Width           int
+	Height          int
+	CellSize        int
+	Color           string
+	BackgroundColor string
+	Interval        int64
+	Stopped         bool
+	Mode            string
+	Rule            *engine.Rule  `json:",omitempty"`
+	Owners          [][]uint8     `json:",omitempty"`
+	Round           *Round        `json:",omitempty"`
+	Chat            []chatMessage `json:",omitempty"`
+	OwnerToken      string
+	InviteToken     string
+}
+
+// saveGames writes every game to path as JSON. The file is replaced
+// atomically, so a crash while saving leaves the previous state intact.
+// Each game is marshalled while its lock is held, as the board, owners,
+// round and chat keep changing underneath.
+func (s *Server) saveGames(path string) error {
+	saved := make(map[string]json.RawMessage)
+	s.mu.Lock()
+	for gameID, game := range s.games {
+		game.mu.Lock()
+		rule := game.Rule
+		data, err := json.Marshal(savedGame{
+			Board:           game.Board,
+			Width:           game.Width,
+			Height:          game.Height,
+			CellSize:        game.CellSize,
+			Color:           game.Color,
+			BackgroundColor: game.BackgroundColor,
+			Interval:        game.Interval,
+			Stopped:         game.Stopped,
+			Mode:            game.Mode,
+			Rule:            &rule,
+			Owners:          game.Owners,
+			Round:           game.Round,
+			Chat:            game.chat,
+			OwnerToken:      game.ownerToken,
+			InviteToken:     game.inviteToken,
+		})
+		game.mu.Unlock()
+		if err != nil {
+			s.mu.Unlock()
+			return fmt.Errorf("game %s: %w", gameID, err)
+		}
+		saved[gameID] = data
+	}
+	s.mu.Unlock()
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	slog.Info("games saved", "count", len(saved), "file", path)
+	return nil
+}
+
+// loadGames restores the games saved by saveGames. A missing file is not an
+// error. Territory rounds caught in their setup phase go back to waiting, as
+// the setup timer did not survive the restart.
+func (s *Server) loadGames(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved map[string]savedGame
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for gameID, sg := range saved {
+		if err := sg.check(); err != nil {
+			slog.Warn("skipping saved game", "gameID", gameID, "err", err)
+			continue
+		}
+		if sg.Round != nil && sg.Round.Phase == phaseSetup {
+			sg.Round.Phase = phaseWaiting
+			sg.Round.Ready = [2]bool{}
+			sg.Stopped = true
+		}
+		// Games saved before rules could change all played B3/S23.
+		if sg.Rule == nil {
+			sg.Rule = &engine.Conway
+		}
+		s.games[gameID] = &Game{
+			GameState: engine.GameState{
+				Board:  sg.Board,
+				Width:  sg.Width,
+				Height: sg.Height,
+				Rule:   *sg.Rule,
+				Owners: sg.Owners,
+				Teams:  len(teamColors[sg.Mode]),
+			},
+			CellSize:        sg.CellSize,
+			Color:           sg.Color,
+			BackgroundColor: sg.BackgroundColor,
+			Interval:        sg.Interval,
+			Stopped:         sg.Stopped,
+			Mode:            sg.Mode,
+			Round:           sg.Round,
+			chat:            sg.Chat,
+			ownerToken:      sg.OwnerToken,
+			inviteToken:     sg.InviteToken,
+		}
+	}
+	slog.Info("games restored", "count", len(s.games), "file", path)
+	return nil
+}
+
+// check makes sure a saved game's board matches its dimensions and its
+// owners name teams of its mode, so a damaged file cannot make Update or the
+// exports index out of range.
+func (s *savedGame) check() error {
+	if _, ok := teamColors[s.Mode]; !ok && s.Mode != modeClassic {
+		return fmt.Errorf("unknown mode %q", s.Mode)
+	}
+	if (s.Owners != nil) != (s.Mode != modeClassic) || (s.Round != nil) != (s.Mode == modeTerritory) {
+		return fmt.Errorf("state does not match mode %q", s.Mode)
+	}
+	if s.Width < 3 || s.Height < 3 || len(s.Board) != s.Height {
+		return fmt.Errorf("board does not match %dx%d", s.Width, s.Height)
+	}
+	teams := len(teamColors[s.Mode])
+	for y := range s.Board {
+		if len(s.Board[y]) != s.Width || (s.Owners != nil && (len(s.Owners) != s.Height || len(s.Owners[y]) != s.Width)) {
+			return fmt.Errorf("row %d does not match width %d", y, s.Width)
+		}
+		if s.Owners == nil {
+			continue
+		}
+		for x, owner := range s.Owners[y] {
+			if int(owner) > teams {
+				return fmt.Errorf("cell (%d, %d) is owned by team %d of %d", x, y, owner, teams)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// savedBoard returns a saved 5x5 game of the given mode with one live cell
+// owned by team 1 in competitive modes.
+func savedBoard(mode string) savedGame {
+	game := newGame(5, 5, 5, "white", "black", int64(time.Second))
+	game.enableTeams(mode)
+	game.Birth(2, 2)
+	sg := savedGame{Board: game.Board, Width: 5, Height: 5, Mode: mode, Owners: game.Owners}
+	if sg.Owners != nil {
+		sg.Owners[2][2] = 1
+	}
+	if mode == modeTerritory {
+		sg.Round = &Round{Phase: phaseWaiting}
+	}
+	return sg
+}
+
+func TestSavedGameCheck(t *testing.T) {
+	for _, mode := range []string{modeClassic, modeImmigration, modeTerritory} {
+		sg := savedBoard(mode)
+		if err := sg.check(); err != nil {
+			t.Errorf("valid %s game rejected: %v", mode, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mode   string
+		damage func(*savedGame)
+		want   string
+	}{
+		{"unknown mode", modeClassic, func(sg *savedGame) { sg.Mode = "chess" }, "unknown mode"},
+		{"owners in a classic game", modeClassic, func(sg *savedGame) { sg.Owners = savedBoard(modeImmigration).Owners }, "does not match mode"},
+		{"no owners in a team game", modeImmigration, func(sg *savedGame) { sg.Owners = nil }, "does not match mode"},
+		{"territory without a round", modeTerritory, func(sg *savedGame) { sg.Round = nil }, "does not match mode"},
+		{"too small", modeClassic, func(sg *savedGame) { sg.Width = 2 }, "does not match 2x5"},
+		{"missing row", modeClassic, func(sg *savedGame) { sg.Board = sg.Board[:4] }, "does not match 5x5"},
+		{"short row", modeClassic, func(sg *savedGame) { sg.Board[3] = sg.Board[3][:4] }, "row 3 does not match"},
+		{"short owner row", modeImmigration, func(sg *savedGame) { sg.Owners[1] = sg.Owners[1][:2] }, "row 1 does not match"},
+		{"owner out of range", modeImmigration, func(sg *savedGame) { sg.Owners[2][2] = 3 }, "owned by team 3 of 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sg := savedBoard(tt.mode)
+			tt.damage(&sg)
+			err := sg.check()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("check() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoadGames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.json")
+	s := New(DefaultConfig())
+	game := newGame(10, 8, 5, "white", "black", int64(time.Second))
+	game.Birth(3, 3)
+	game.ownerToken, game.inviteToken = "owner", "invite"
+	game.chat = []chatMessage{{Name: "Ada", Text: "hi"}}
+	s.games["g"] = game
+	if err := s.saveGames(path); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := New(DefaultConfig())
+	if err := restored.loadGames(path); err != nil {
+		t.Fatal(err)
+	}
+	got := restored.games["g"]
+	if got == nil || !got.Alive(3, 3) || got.LiveCells() != 1 || got.ownerToken != "owner" || got.inviteToken != "invite" || len(got.chat) != 1 {
+		t.Fatalf("restored game lost its board, tokens or chat: %+v", got)
+	}
+}
+
+func TestLoadGamesSkipsDamagedGames(t *testing.T) {
+	bad := savedBoard(modeImmigration)
+	bad.Owners[2][2] = 200
+	data, err := json.Marshal(map[string]savedGame{"good": savedBoard(modeClassic), "bad": bad})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "games.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(DefaultConfig())
+	if err := s.loadGames(path); err != nil {
+		t.Fatal(err)
+	}
+	if s.games["good"] == nil || s.games["bad"] != nil {
+		t.Errorf("restored %d games, want only the undamaged one", len(s.games))
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// maxNameLength bounds player names in runes.
+const maxNameLength = 32
+
+// actionFields are the message fields copied into action broadcasts so
+// other players can see what was done and where.
+var actionFields = []string{"x", "y", "pattern", "percentage", "color", "width", "height", "anchor"}
+
+// playerInfo is one entry of the presence list.
+type playerInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+	Team int    `json:"team,omitempty"`
+}
+
+// assignPlayerID gives a new connection its ID and default name. The caller
+// must hold s.mu.
+func (s *Server) assignPlayerID(client *Client) {
+	s.nextPlayerSeq++
+	client.seq = s.nextPlayerSeq
+	client.id = fmt.Sprintf("player-%d", client.seq)
+	client.name = fmt.Sprintf("Player %d", client.seq)
+}
+
+// sanitizeName strips control characters and surrounding space from a
+// requested name and truncates it. It returns "" if nothing usable is left.
+func sanitizeName(name string) string {
+	name = strings.TrimSpace(stripControl(name))
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+	return name
+}
+
+// stripControl removes control characters, such as newlines and terminal
+// escapes, from text that players and operators send to other players.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// setName renames a client if the message carries a usable "name" field.
+// The caller must hold s.mu.
+func setName(client *Client, msg map[string]interface{}) {
+	if name, ok := msg["name"].(string); ok {
+		if name = sanitizeName(name); name != "" {
+			client.name = name
+		}
+	}
+}
+
+// broadcastPresence sends the list of players in a game to all of them.
+func (s *Server) broadcastPresence(gameID string) {
+	if gameID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var members []*Client
+	for client := range s.clients {
+		if client.gameID == gameID {
+			members = append(members, client)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].seq < members[j].seq })
+	players := make([]playerInfo, len(members))
+	for i, client := range members {
+		players[i] = playerInfo{ID: client.id, Name: client.name, Role: client.role, Team: client.team}
+	}
+	s.broadcastMessage(gameID, map[string]interface{}{"type": protocol.TypePresence, "gameID": gameID, "players": players})
+	slog.Debug("presence sent", "gameID", gameID, "players", len(players))
+}
+
+// broadcastAction tells everyone in a game which player changed the board.
+func (s *Server) broadcastAction(client *Client, gameID string, msg map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	action := map[string]interface{}{
+		"type":     protocol.TypeAction,
+		"action":   msg["type"],
+		"playerID": client.id,
+		"name":     client.name,
+	}
+	for _, field := range actionFields {
+		if v, ok := msg[field]; ok {
+			action[field] = v
+		}
+	}
+	s.broadcastMessage(gameID, action)
+	slog.Debug("action broadcast", "gameID", gameID, "clientID", client.id, "type", msg["type"])
+}
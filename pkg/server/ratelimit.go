@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// rateLimit is a token bucket setting: rate messages per second on average,
+// with bursts of up to burst messages.
+type rateLimit struct {
+	rate  float64
+	burst float64
+}
+
+// defaultLimit applies to every message, whatever its type, on top of the
+// per-type limits in messageLimits.
+var defaultLimit = rateLimit{rate: 50, burst: 100}
+
+// messageLimits are the per-type limits of a connection. The expensive
+// messages, which lock the board and trigger a full broadcast, get the
+// tightest ones.
+var messageLimits = map[string]rateLimit{
+	protocol.TypeInit:               {rate: 1, burst: 5},
+	protocol.TypeJoin:               {rate: 1, burst: 5},
+	protocol.TypeBirth:              {rate: 20, burst: 40},
+	protocol.TypeStop:               {rate: 2, burst: 5},
+	protocol.TypeResume:             {rate: 2, burst: 5},
+	protocol.TypeSetBackgroundColor: {rate: 2, burst: 5},
+	protocol.TypeClear:              {rate: 1, burst: 3},
+	protocol.TypeRandomBirth:        {rate: 0.5, burst: 2},
+	protocol.TypePattern:            {rate: 1, burst: 5},
+	protocol.TypeSetName:            {rate: 1, burst: 3},
+	protocol.TypeChat:               {rate: 2, burst: 5},
+	protocol.TypeStartRound:         {rate: 1, burst: 3},
+	protocol.TypeReady:              {rate: 2, burst: 5},
+	protocol.TypeCursor:             {rate: 30, burst: 60},
+	protocol.TypeSelection:          {rate: 30, burst: 60},
+	protocol.TypeResize:             {rate: 1, burst: 5},
+	protocol.TypeViewport:           {rate: 10, burst: 20},
+}
+
+// violationLimit is how often a connection may exceed its limits before it
+// is disconnected: a client that keeps sending after being told to slow
+// down is dropped.
+var violationLimit = rateLimit{rate: 0.2, burst: 10}
+
+// tokenBucket is a single rate limit. It is only used by the goroutine
+// reading the client's connection, so it needs no locking.
+type tokenBucket struct {
+	rateLimit
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit rateLimit) *tokenBucket {
+	return &tokenBucket{rateLimit: limit, tokens: limit.burst, last: time.Now()}
+}
+
+// allow takes a token from the bucket if there is one.
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter holds the token buckets of one connection.
+type rateLimiter struct {
+	all        *tokenBucket
+	byType     map[string]*tokenBucket
+	violations *tokenBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		all:        newTokenBucket(defaultLimit),
+		byType:     make(map[string]*tokenBucket),
+		violations: newTokenBucket(violationLimit),
+	}
+}
+
+// check reports whether a message of type msgType is within the limits. If
+// it is not, disconnect reports whether the client has exceeded them so
+// often that it should be dropped.
+func (l *rateLimiter) check(msgType string) (ok, disconnect bool) {
+	now := time.Now()
+	bucket := l.byType[msgType]
+	if bucket == nil {
+		if limit, known := messageLimits[msgType]; known {
+			bucket = newTokenBucket(limit)
+			l.byType[msgType] = bucket
+		}
+	}
+	if l.all.allow(now) && (bucket == nil || bucket.allow(now)) {
+		return true, false
+	}
+	return false, !l.violations.allow(now)
+}
+
+// limitMessage applies the client's rate limits to msg. It returns false if
+// msg must be dropped, after telling the client why or disconnecting it.
+func (s *Server) limitMessage(client *Client, msg map[string]interface{}) bool {
+	msgType, _ := msg["type"].(string)
+	ok, disconnect := client.limiter.check(msgType)
+	switch {
+	case ok:
+		return true
+	case disconnect:
+		slog.Warn("disconnecting client for exceeding rate limits", "gameID", client.gameID, "clientID", client.id, "type", msgType)
+		s.closeClient(client, websocket.ClosePolicyViolation, "rate limit exceeded")
+	default:
+		slog.Info("message dropped by rate limit", "gameID", client.gameID, "clientID", client.id, "type", msgType)
+		s.metrics.droppedMessages.add("rate_limit", 1)
+		s.sendError(client, fmt.Sprintf("rate limit exceeded for %s, slow down", msgType))
+	}
+	return false
+}
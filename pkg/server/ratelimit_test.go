@@ -0,0 +1,49 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"GameOfLife/pkg/protocol"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Now()
+	b := &tokenBucket{rateLimit: rateLimit{rate: 2, burst: 3}, tokens: 3, last: start}
+	for i := range 3 {
+		if !b.allow(start) {
+			t.Fatalf("message %d of the burst refused", i+1)
+		}
+	}
+	if b.allow(start) {
+		t.Error("message beyond the burst allowed")
+	}
+	if !b.allow(start.Add(500 * time.Millisecond)) {
+		t.Error("message refused after a token was refilled")
+	}
+	if b.allow(start.Add(500 * time.Millisecond)) {
+		t.Error("refill granted more than rate allows")
+	}
+}
+
+func TestRateLimiterDisconnectsRepeatOffenders(t *testing.T) {
+	l := newRateLimiter()
+	limit := messageLimits[protocol.TypeClear]
+	for i := range int(limit.burst) {
+		if ok, _ := l.check(protocol.TypeClear); !ok {
+			t.Fatalf("clear %d of the burst refused", i+1)
+		}
+	}
+	// Other types have their own buckets.
+	if ok, _ := l.check(protocol.TypeBirth); !ok {
+		t.Error("birth refused because clear ran out")
+	}
+	for i := range int(violationLimit.burst) {
+		if ok, disconnect := l.check(protocol.TypeClear); ok || disconnect {
+			t.Fatalf("violation %d: ok %v, disconnect %v; want a refusal without disconnecting", i+1, ok, disconnect)
+		}
+	}
+	if _, disconnect := l.check(protocol.TypeClear); !disconnect {
+		t.Error("client not disconnected after using up its violations")
+	}
+}
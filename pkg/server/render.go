@@ -0,0 +1,217 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"GameOfLife/pkg/engine"
+)
+
+const (
+	defaultClipFrames = 30
+	maxClipFrames     = 200
+
+	// maxImagePixels caps the size of one rendered image. Larger boards are
+	// drawn with smaller cells than the game's CellSize.
+	maxImagePixels = 4 << 20
+	// maxClipPixels caps the pixels of all the frames of a GIF clip together.
+	maxClipPixels = 64 << 20
+)
+
+// namedColors covers the colour names the browser client sends.
+var namedColors = map[string]color.RGBA{
+	"black": {0x00, 0x00, 0x00, 0xff},
+	"white": {0xff, 0xff, 0xff, 0xff},
+	"red":   {0xff, 0x00, 0x00, 0xff},
+	"green": {0x00, 0x80, 0x00, 0xff},
+	"blue":  {0x00, 0x00, 0xff, 0xff},
+	"gray":  {0x80, 0x80, 0x80, 0xff},
+	"grey":  {0x80, 0x80, 0x80, 0xff},
+}
+
+// parseColor understands "#rgb", "#rrggbb" and the names in namedColors,
+// falling back to fallback for anything else.
+func parseColor(s string, fallback color.RGBA) color.RGBA {
+	if c, ok := lookupColor(s); ok {
+		return c
+	}
+	return fallback
+}
+
+// lookupColor is parseColor without a fallback: ok is false if s is not a
+// known name or hex colour.
+func lookupColor(s string) (c color.RGBA, ok bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+	if !strings.HasPrefix(s, "#") {
+		return c, false
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return c, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return c, false
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, true
+}
+
+// snapshot returns a copy of the game that can be rendered or advanced
+// without holding the live game's lock.
+func (g *Game) snapshot() *Game {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return &Game{
+		GameState:       *g.Clone(),
+		CellSize:        g.CellSize,
+		Color:           g.Color,
+		BackgroundColor: g.BackgroundColor,
+		Interval:        g.Interval,
+		Stopped:         g.Stopped,
+		Mode:            g.Mode,
+	}
+}
+
+// imageCellSize returns the largest cell size up to the game's CellSize at
+// which the playable area fits in maxPixels, or an error if not even one
+// pixel per cell does.
+func (g *Game) imageCellSize(maxPixels int) (int, error) {
+	w, h := max(g.Width-2, 1), max(g.Height-2, 1)
+	if w*h > maxPixels {
+		return 0, fmt.Errorf("board %dx%d is too large to render within %d pixels", g.Width, g.Height, maxPixels)
+	}
+	cellSize := max(g.CellSize, 1)
+	for cellSize > 1 && w*h*cellSize*cellSize > maxPixels {
+		cellSize--
+	}
+	return cellSize, nil
+}
+
+// renderImage draws the playable area of the board, one cellSize square per
+// cell, onto a paletted image. Competitive games use their team colours.
+func (g *Game) renderImage(cellSize int) *image.Paletted {
+	w, h := max(g.Width-2, 0), max(g.Height-2, 0)
+	palette := color.Palette{
+		parseColor(g.BackgroundColor, namedColors["black"]),
+		parseColor(g.Color, namedColors["white"]),
+	}
+	for _, c := range teamColors[g.Mode] {
+		palette = append(palette, parseColor(c, namedColors["white"]))
+	}
+	img := image.NewPaletted(image.Rect(0, 0, w*cellSize, h*cellSize), palette)
+	for y := 1; y < g.Height-1; y++ {
+		for x := 1; x < g.Width-1; x++ {
+			if g.Board[y][x] < engine.Alive {
+				continue
+			}
+			index := uint8(1)
+			if g.Owners != nil && g.Owners[y][x] > 0 {
+				index = 1 + g.Owners[y][x]
+			}
+			for py := (y - 1) * cellSize; py < y*cellSize; py++ {
+				for px := (x - 1) * cellSize; px < x*cellSize; px++ {
+					img.SetColorIndex(px, py, index)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// lookupGame finds the game named by the {id} path segment, replying with
+// 404 if it does not exist.
+func (s *Server) lookupGame(w http.ResponseWriter, r *http.Request) (*Game, string, bool) {
+	gameID := r.PathValue("id")
+	s.mu.Lock()
+	game, exists := s.games[gameID]
+	s.mu.Unlock()
+	if !exists {
+		http.Error(w, fmt.Sprintf("game %q not found", gameID), http.StatusNotFound)
+		return nil, gameID, false
+	}
+	return game, gameID, true
+}
+
+// snapshotPNGHandler serves the current generation of a game as a PNG.
+func (s *Server) snapshotPNGHandler(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	sim := game.snapshot()
+	cellSize, err := sim.imageCellSize(maxImagePixels)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, sim.renderImage(cellSize)); err != nil {
+		slog.Warn("PNG encoding failed", "gameID", gameID, "err", err)
+		http.Error(w, "failed to render snapshot", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+	slog.Info("PNG snapshot served", "gameID", gameID)
+}
+
+// clipGIFHandler serves an animated GIF of the next generations of a game.
+// The simulation runs on a copy of the board so the live game is untouched.
+func (s *Server) clipGIFHandler(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	frames := defaultClipFrames
+	if v := r.URL.Query().Get("frames"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxClipFrames {
+			http.Error(w, fmt.Sprintf("frames must be between 1 and %d", maxClipFrames), http.StatusBadRequest)
+			return
+		}
+		frames = n
+	}
+
+	sim := game.snapshot()
+	cellSize, err := sim.imageCellSize(min(maxImagePixels, maxClipPixels/frames))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%v; ask for fewer frames", err), http.StatusRequestEntityTooLarge)
+		return
+	}
+	delay := max(int(time.Duration(sim.Interval)/(10*time.Millisecond)), 2)
+	anim := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		if i > 0 {
+			sim.Update()
+		}
+		anim.Image = append(anim.Image, sim.renderImage(cellSize))
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		slog.Warn("GIF encoding failed", "gameID", gameID, "err", err)
+		http.Error(w, "failed to render clip", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+	slog.Info("GIF clip served", "gameID", gameID, "frames", frames)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// Roles a client can hold in the game it has joined. The owner created the
+// game, editors joined with the invite token and spectators can only watch.
+const (
+	roleOwner     = "owner"
+	roleEditor    = "editor"
+	roleSpectator = "spectator"
+)
+
+// mutatingMessages lists the message types that change a game and are
+// therefore refused for spectators.
+var mutatingMessages = map[string]bool{
+	protocol.TypeBirth:              true,
+	protocol.TypeStop:               true,
+	protocol.TypeResume:             true,
+	protocol.TypeSetBackgroundColor: true,
+	protocol.TypeClear:              true,
+	protocol.TypeRandomBirth:        true,
+	protocol.TypePattern:            true,
+	protocol.TypeStartRound:         true,
+	protocol.TypeReady:              true,
+	protocol.TypeResize:             true,
+}
+
+// newToken returns a random hex token for owner and invite links.
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("generating token: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// roleFor maps a token presented by a joining client to its role.
+func (g *Game) roleFor(token string) string {
+	switch {
+	case token == "":
+		return roleSpectator
+	case subtle.ConstantTimeCompare([]byte(token), []byte(g.ownerToken)) == 1:
+		return roleOwner
+	case subtle.ConstantTimeCompare([]byte(token), []byte(g.inviteToken)) == 1:
+		return roleEditor
+	default:
+		return roleSpectator
+	}
+}
+
+// canEdit reports whether the client may send mutating messages.
+func (c *Client) canEdit() bool {
+	return c.role == roleOwner || c.role == roleEditor
+}
+
+// sendRole tells a client which role it holds, along with its player ID and
+// name. Owners also receive the owner token, to reclaim the game after
+// reconnecting, and the invite token to hand out to editors.
+func (s *Server) sendRole(client *Client, game *Game) {
+	reply := map[string]interface{}{"type": protocol.TypeRole, "role": client.role, "playerID": client.id, "name": client.name}
+	if client.team > 0 {
+		reply["team"] = client.team
+	}
+	if client.role == roleOwner {
+		reply["ownerToken"] = game.ownerToken
+		reply["inviteToken"] = game.inviteToken
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := client.conn.WriteJSON(reply); err != nil {
+		slog.Warn("sending role failed", "gameID", client.gameID, "clientID", client.id, "err", err)
+	}
+}
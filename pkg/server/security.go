@@ -0,0 +1,68 @@
+package server
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// checkOrigin returns the upgrader's CheckOrigin for a list of allowed
+// origins such as "https://life.example.com". With no list only pages served
+// by this server may connect, and "*" allows any origin. Requests without an
+// Origin header come from non-browser clients and are always allowed.
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if len(allowed) == 0 {
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		}
+		for _, a := range allowed {
+			if a == "*" || strings.EqualFold(a, origin) {
+				return true
+			}
+		}
+		slog.Warn("websocket origin rejected", "origin", origin, "remoteAddr", r.RemoteAddr)
+		return false
+	}
+}
+
+// parseOrigins splits a comma-separated list of origins, dropping empty
+// entries and trailing slashes.
+func parseOrigins(list string) []string {
+	var origins []string
+	for _, origin := range strings.Split(list, ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// requireAuth protects next with a shared secret, presented either as an
+// "Authorization: Bearer <secret>" header or, for browsers opening a
+// WebSocket, as an "auth" query parameter. An empty secret disables the
+// check.
+func requireAuth(secret string, next http.Handler) http.Handler {
+	if secret == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented := r.URL.Query().Get("auth")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			presented = bearer
+		}
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(secret)) != 1 {
+			slog.Warn("unauthenticated request rejected", "path", r.URL.Path, "remoteAddr", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="GameOfLife"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,74 @@
+// Package server is the Game of Life server: it hosts games, advances them
+// in a game loop and talks to their players over WebSockets, and serves the
+// browser client, the HTTP API and the operator endpoints.
+//
+//	srv := server.New(server.DefaultConfig())
+//	err := srv.ListenAndServe(ctx) // until ctx is cancelled
+package server
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// Server hosts a set of games and their connected players.
+type Server struct {
+	cfg      Config
+	upgrader websocket.Upgrader
+
+	// mu guards games, clients and nextPlayerSeq, and serialises writes to
+	// the clients' connections. Take it before any game's mu.
+	mu            trackedMutex
+	games         map[string]*Game
+	clients       map[*Client]bool
+	nextPlayerSeq int // numbers connections in arrival order
+
+	readiness  atomic.Value // one of the state* readiness constants
+	loopActive atomic.Int64 // when the game loop last started on a game, in Unix nanoseconds
+
+	metrics     *metrics
+	tickSamples sync.Map // per-tick log message -> *atomic.Uint64 count, for logTick
+}
+
+// New returns a server with no games for the given configuration, which
+// should have been checked by LoadConfig.
+func New(cfg Config) *Server {
+	s := &Server{
+		cfg: cfg,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(parseOrigins(cfg.AllowedOrigins)),
+		},
+		games:   make(map[string]*Game),
+		clients: make(map[*Client]bool),
+		metrics: newMetrics(),
+	}
+	s.readiness.Store(stateStarting)
+	return s
+}
+
+// Handler returns the server's HTTP routes: the WebSocket endpoint, the
+// HTTP API, health checks, the admin API and debug endpoints when enabled,
+// and the browser client.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", healthzHandler)
+	mux.HandleFunc("GET /readyz", s.readyzHandler)
+	mux.Handle("/ws", requireAuth(s.cfg.AuthToken, http.HandlerFunc(s.wsHandler)))
+	mux.Handle("GET /api/games/{id}/snapshot.png", requireAuth(s.cfg.AuthToken, http.HandlerFunc(s.snapshotPNGHandler)))
+	mux.Handle("GET /api/games/{id}/clip.gif", requireAuth(s.cfg.AuthToken, http.HandlerFunc(s.clipGIFHandler)))
+	mux.Handle("GET /api/games/{id}/snapshot.svg", requireAuth(s.cfg.AuthToken, http.HandlerFunc(s.snapshotSVGHandler)))
+	mux.Handle("GET /metrics", requireAuth(s.cfg.AuthToken, http.HandlerFunc(s.metricsHandler)))
+	if s.cfg.AdminToken != "" {
+		s.registerAdmin(mux)
+	}
+	if s.cfg.Debug {
+		s.registerDebug(mux)
+	}
+	mux.HandleFunc("/", s.serveHandler)
+	return mux
+}
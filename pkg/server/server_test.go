@@ -0,0 +1,549 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"GameOfLife/pkg/protocol"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// newTestServer serves a server with the default configuration until the
+// test ends. The game loop does not run, so boards only change in response
+// to messages.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(New(DefaultConfig()).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testConn is a raw WebSocket connection to a test server.
+type testConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dial(t *testing.T, srv *httptest.Server) *testConn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", url, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testConn{t: t, conn: conn}
+}
+
+func (c *testConn) send(msg map[string]interface{}) {
+	c.t.Helper()
+	if err := c.conn.WriteJSON(msg); err != nil {
+		c.t.Fatalf("send %v: %v", msg["type"], err)
+	}
+}
+
+// next reads messages until one of type msgType arrives and returns it
+// undecoded. The board state has no type, so "" waits for the next state.
+func (c *testConn) next(msgType string) []byte {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.t.Fatalf("waiting for %q message: %v", msgType, err)
+		}
+		var header struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &header); err != nil {
+			c.t.Fatalf("message is not JSON: %s", data)
+		}
+		if header.Type == msgType {
+			return data
+		}
+	}
+}
+
+// state waits for the next board state.
+func (c *testConn) state() (protocol.BoardState, [][]uint8) {
+	c.t.Helper()
+	var state protocol.BoardState
+	if err := json.Unmarshal(c.next(""), &state); err != nil {
+		c.t.Fatalf("decoding board state: %v", err)
+	}
+	board, err := protocol.DecodeRows(state.Board)
+	if err != nil {
+		c.t.Fatalf("decoding board: %v", err)
+	}
+	return state, board
+}
+
+// init creates gameID with an empty width x height board and returns its
+// first state.
+func (c *testConn) init(gameID string, width, height int) (protocol.BoardState, [][]uint8) {
+	c.t.Helper()
+	c.send(map[string]interface{}{"type": protocol.TypeInit, "gameID": gameID, "width": width, "height": height, "cellSize": 5, "fill": "empty"})
+	return c.state()
+}
+
+func liveCells(board [][]uint8) int {
+	n := 0
+	for _, row := range board {
+		for _, cell := range row {
+			if cell >= protocol.Alive {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestInitCreatesGame(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.send(map[string]interface{}{"type": protocol.TypeInit, "gameID": "g1", "width": 30, "height": 20, "cellSize": 5, "fill": "empty"})
+
+	var role struct {
+		Role       string `json:"role"`
+		PlayerID   string `json:"playerID"`
+		OwnerToken string `json:"ownerToken"`
+	}
+	if err := json.Unmarshal(c.next(protocol.TypeRole), &role); err != nil {
+		t.Fatal(err)
+	}
+	if role.Role != roleOwner || role.PlayerID == "" || role.OwnerToken == "" {
+		t.Errorf("role = %+v, want an owner with a player ID and owner token", role)
+	}
+
+	state, board := c.state()
+	if state.Width != 30 || state.Height != 20 || state.CellSize != 5 {
+		t.Errorf("board is %dx%d with cell size %d, want 30x20 with cell size 5", state.Width, state.Height, state.CellSize)
+	}
+	if len(board) != 20 || len(board[0]) != 30 {
+		t.Errorf("board rows are %dx%d, want 30x20", len(board[0]), len(board))
+	}
+	if n := liveCells(board); n != 0 {
+		t.Errorf("empty board has %d live cells", n)
+	}
+	if state.Mode != modeClassic || state.Rule != "B3/S23" {
+		t.Errorf("mode %q rule %q, want %q and B3/S23", state.Mode, state.Rule, modeClassic)
+	}
+}
+
+func TestInitRejectsOversizedBoard(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.send(map[string]interface{}{"type": protocol.TypeInit, "gameID": "g1", "width": 100000, "height": 20, "cellSize": 5})
+	var reply struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(c.next(protocol.TypeError), &reply)
+	if !strings.Contains(reply.Message, "larger than") {
+		t.Errorf("error = %q, want the size limit", reply.Message)
+	}
+}
+
+func TestJoinUnknownGame(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.send(map[string]interface{}{"type": protocol.TypeJoin, "gameID": "missing"})
+	var reply struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(c.next(protocol.TypeError), &reply)
+	if reply.Message != "game missing not found" {
+		t.Errorf("error = %q, want game missing not found", reply.Message)
+	}
+}
+
+func TestBirth(t *testing.T) {
+	tests := []struct {
+		name  string
+		x, y  int
+		alive bool
+	}{
+		{name: "inside the board", x: 5, y: 4, alive: true},
+		{name: "on the border", x: 0, y: 4, alive: false},
+		{name: "off the board", x: 50, y: 4, alive: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dial(t, newTestServer(t))
+			c.init("g1", 20, 10)
+			c.send(map[string]interface{}{"type": protocol.TypeBirth, "gameID": "g1", "x": tt.x, "y": tt.y})
+			_, board := c.state()
+			want := 0
+			if tt.alive {
+				want = 1
+				if board[tt.y][tt.x] < protocol.Alive {
+					t.Errorf("cell (%d, %d) is dead after birth", tt.x, tt.y)
+				}
+			}
+			if n := liveCells(board); n != want {
+				t.Errorf("board has %d live cells, want %d", n, want)
+			}
+		})
+	}
+}
+
+func TestBirthReachesOtherPlayers(t *testing.T) {
+	srv := newTestServer(t)
+	owner := dial(t, srv)
+	owner.init("g1", 20, 10)
+	player := dial(t, srv)
+	player.send(map[string]interface{}{"type": protocol.TypeJoin, "gameID": "g1"})
+	player.state()
+	owner.state() // the join is broadcast to the owner too
+
+	owner.send(map[string]interface{}{"type": protocol.TypeBirth, "gameID": "g1", "x": 3, "y": 3})
+	_, board := player.state()
+	if board[3][3] < protocol.Alive {
+		t.Error("the other player did not see the birth")
+	}
+}
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		cells   int
+	}{
+		{pattern: "glider", cells: 5},
+		{pattern: "blinker", cells: 3},
+		{pattern: "toad", cells: 6},
+		{pattern: "unknown", cells: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			c := dial(t, newTestServer(t))
+			c.init("g1", 40, 30)
+			c.send(map[string]interface{}{"type": protocol.TypePattern, "gameID": "g1", "pattern": tt.pattern})
+			_, board := c.state()
+			if n := liveCells(board); n != tt.cells {
+				t.Errorf("board has %d live cells after %s, want %d", n, tt.pattern, tt.cells)
+			}
+		})
+	}
+}
+
+func TestClear(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.send(map[string]interface{}{"type": protocol.TypeInit, "gameID": "g1", "width": 30, "height": 20, "cellSize": 5, "fill": "random", "density": 50})
+	_, board := c.state()
+	if liveCells(board) == 0 {
+		t.Fatal("random fill left the board empty")
+	}
+	c.send(map[string]interface{}{"type": protocol.TypeClear, "gameID": "g1"})
+	_, board = c.state()
+	if n := liveCells(board); n != 0 {
+		t.Errorf("board has %d live cells after clear", n)
+	}
+}
+
+func TestSpectatorCannotEdit(t *testing.T) {
+	srv := newTestServer(t)
+	owner := dial(t, srv)
+	owner.init("g1", 20, 10)
+	// Without the invite token a second player joins as a spectator.
+	spectator := dial(t, srv)
+	spectator.send(map[string]interface{}{"type": protocol.TypeJoin, "gameID": "g1"})
+	var role struct {
+		Role string `json:"role"`
+	}
+	json.Unmarshal(spectator.next(protocol.TypeRole), &role)
+	if role.Role != roleSpectator {
+		t.Fatalf("joined without a token as %q, want %q", role.Role, roleSpectator)
+	}
+	spectator.send(map[string]interface{}{"type": protocol.TypeClear, "gameID": "g1"})
+	var reply struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(spectator.next(protocol.TypeError), &reply)
+	if reply.Message != "spectators cannot send clear" {
+		t.Errorf("error = %q, want spectators cannot send clear", reply.Message)
+	}
+}
+
+func TestResize(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.init("g1", 20, 10)
+	c.send(map[string]interface{}{"type": protocol.TypeBirth, "gameID": "g1", "x": 3, "y": 3})
+	c.state()
+
+	c.send(map[string]interface{}{"type": protocol.TypeResize, "gameID": "g1", "width": 30, "height": 16, "anchor": "bottom-right"})
+	state, board := c.state()
+	if state.Width != 30 || state.Height != 16 || len(board) != 16 || len(board[0]) != 30 {
+		t.Fatalf("state is %dx%d with a %dx%d board, want 30x16", state.Width, state.Height, len(board[0]), len(board))
+	}
+	if board[9][13] < protocol.Alive || liveCells(board) != 1 {
+		t.Error("the live cell did not move with the bottom-right corner to (13, 9)")
+	}
+}
+
+func TestResizeRejected(t *testing.T) {
+	srv := newTestServer(t)
+	owner := dial(t, srv)
+	owner.send(map[string]interface{}{"type": protocol.TypeInit, "gameID": "g1", "width": 20, "height": 10, "cellSize": 5, "fill": "empty"})
+	var role struct {
+		InviteToken string `json:"inviteToken"`
+	}
+	json.Unmarshal(owner.next(protocol.TypeRole), &role)
+	owner.state()
+	editor := dial(t, srv)
+	editor.send(map[string]interface{}{"type": protocol.TypeJoin, "gameID": "g1", "token": role.InviteToken})
+	editor.state()
+
+	tests := []struct {
+		name string
+		c    *testConn
+		msg  map[string]interface{}
+		want string
+	}{
+		{"editor", editor, map[string]interface{}{"width": 30, "height": 10}, "only the owner can resize the board"},
+		{"too large", owner, map[string]interface{}{"width": 100000, "height": 10}, "larger than"},
+		{"too small", owner, map[string]interface{}{"width": 2, "height": 10}, "smaller than"},
+		{"unknown anchor", owner, map[string]interface{}{"width": 30, "height": 10, "anchor": "middle"}, "anchor must be one of"},
+		{"missing height", owner, map[string]interface{}{"width": 30}, "resize needs a numeric height"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.msg["type"] = protocol.TypeResize
+			tt.msg["gameID"] = "g1"
+			tt.c.send(tt.msg)
+			var reply struct {
+				Message string `json:"message"`
+			}
+			json.Unmarshal(tt.c.next(protocol.TypeError), &reply)
+			if !strings.Contains(reply.Message, tt.want) {
+				t.Errorf("error = %q, want %q", reply.Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewport(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.init("g1", 20, 12)
+	// A 2x2 block at (4,4)-(5,5) and a lone cell at (10, 6).
+	for _, p := range [][2]int{{4, 4}, {5, 4}, {4, 5}, {5, 5}, {10, 6}} {
+		c.send(map[string]interface{}{"type": protocol.TypeBirth, "gameID": "g1", "x": p[0], "y": p[1]})
+		c.state()
+	}
+
+	c.send(map[string]interface{}{"type": protocol.TypeViewport, "gameID": "g1", "x": 3, "y": 4, "width": 8, "height": 3})
+	state, board := c.state()
+	if state.View == nil || *state.View != (protocol.Viewport{X: 3, Y: 4, Width: 8, Height: 3, Zoom: 1}) {
+		t.Fatalf("View = %+v, want the requested 8x3 rectangle at (3, 4)", state.View)
+	}
+	if state.Width != 20 || state.Height != 12 {
+		t.Errorf("state is %dx%d, want the size of the whole board", state.Width, state.Height)
+	}
+	if len(board) != 3 || len(board[0]) != 8 {
+		t.Fatalf("board is %dx%d, want 8x3", len(board[0]), len(board))
+	}
+	if board[0][1] < protocol.Alive || board[1][2] < protocol.Alive || board[2][7] < protocol.Alive || liveCells(board) != 5 {
+		t.Error("the viewport does not hold the block and the lone cell at their offsets")
+	}
+
+	// Later states keep to the viewport, clipped to the board.
+	c.send(map[string]interface{}{"type": protocol.TypeViewport, "gameID": "g1", "x": 15, "y": -3, "width": 10, "height": 6})
+	c.state()
+	c.send(map[string]interface{}{"type": protocol.TypeClear, "gameID": "g1"})
+	state, board = c.state()
+	if *state.View != (protocol.Viewport{X: 15, Y: 0, Width: 5, Height: 3, Zoom: 1}) || len(board) != 3 || len(board[0]) != 5 {
+		t.Errorf("View = %+v with a %dx%d board, want the 5x3 part on the board", state.View, len(board[0]), len(board))
+	}
+
+	c.send(map[string]interface{}{"type": protocol.TypeViewport, "gameID": "g1", "x": 0, "y": 0, "width": 0, "height": 0})
+	state, board = c.state()
+	if state.View != nil || len(board) != 12 {
+		t.Errorf("after unsubscribing View = %+v with %d rows, want the whole board", state.View, len(board))
+	}
+}
+
+func TestViewportZoom(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.init("g1", 20, 12)
+	for _, p := range [][2]int{{4, 4}, {5, 4}, {4, 5}, {5, 5}, {10, 6}} {
+		c.send(map[string]interface{}{"type": protocol.TypeBirth, "gameID": "g1", "x": p[0], "y": p[1]})
+		c.state()
+	}
+	// The viewport widens to whole 4x4 blocks: (0,0) to (12,8).
+	c.send(map[string]interface{}{"type": protocol.TypeViewport, "gameID": "g1", "x": 1, "y": 1, "width": 10, "height": 6, "zoom": 4})
+	var state protocol.BoardState
+	if err := json.Unmarshal(c.next(""), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.View == nil || *state.View != (protocol.Viewport{X: 0, Y: 0, Width: 12, Height: 8, Zoom: 4}) {
+		t.Fatalf("View = %+v, want 12x8 at (0, 0) in blocks of 4", state.View)
+	}
+	if state.Board != nil {
+		t.Error("a zoomed out state carries Board")
+	}
+	density, err := protocol.DecodeRows(state.Density)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]uint8{{0, 0, 0}, {0, 63, 15}}
+	if !reflect.DeepEqual(density, want) {
+		t.Errorf("Density = %v, want %v", density, want)
+	}
+}
+
+func TestViewportRejected(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.init("g1", 20, 12)
+	for _, tt := range []struct {
+		msg  map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"x": 1, "y": 1, "width": 5}, "viewport needs a numeric height"},
+		{map[string]interface{}{"x": 1, "y": 1, "width": 5, "height": 5, "zoom": 0}, "zoom must be between 1 and 64"},
+		{map[string]interface{}{"x": 1, "y": 1, "width": 5, "height": 5, "zoom": 1000}, "zoom must be between 1 and 64"},
+	} {
+		tt.msg["type"] = protocol.TypeViewport
+		tt.msg["gameID"] = "g1"
+		c.send(tt.msg)
+		var reply struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(c.next(protocol.TypeError), &reply)
+		if reply.Message != tt.want {
+			t.Errorf("error = %q, want %q", reply.Message, tt.want)
+		}
+	}
+}
+
+func TestImageExportsAreCapped(t *testing.T) {
+	s := New(DefaultConfig())
+	s.games["big"] = newGame(2000, 2000, maxCellSize, "white", "black", int64(time.Second))
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/api/games/big/snapshot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	cfg, err := png.DecodeConfig(resp.Body)
+	if err != nil {
+		t.Fatalf("decoding snapshot: %v", err)
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		t.Errorf("snapshot is %dx%d, more than %d pixels", cfg.Width, cfg.Height, maxImagePixels)
+	}
+
+	// 200 frames of even one pixel per cell exceed the clip budget.
+	resp, err = http.Get(srv.URL + "/api/games/big/clip.gif?frames=200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("clip of 200 frames: status %d, want %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestSVGExport(t *testing.T) {
+	s := New(DefaultConfig())
+	small := newGame(10, 10, 5, "white", "black", int64(time.Second))
+	small.enableTeams(modeImmigration)
+	small.Birth(3, 3)
+	small.Owners[3][3] = 9 // no team has this number
+	s.games["small"] = small
+	s.games["big"] = newGame(2000, 2000, 1, "white", "black", int64(time.Second))
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name, path string
+		want       int
+	}{
+		{"unknown owner", "/api/games/small/snapshot.svg", http.StatusOK},
+		{"board over the cap", "/api/games/big/snapshot.svg", http.StatusRequestEntityTooLarge},
+		{"crop within the cap", "/api/games/big/snapshot.svg?crop=1,1,500,500", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricsArePerServer(t *testing.T) {
+	busy, idle := New(DefaultConfig()), New(DefaultConfig())
+	busy.metrics.countMessage(protocol.TypeBirth)
+
+	rec := httptest.NewRecorder()
+	idle.metricsHandler(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "gol_messages_total{") {
+		t.Errorf("idle server reports messages handled by another server:\n%s", rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "\ngol_clients_connected 0\n") {
+		t.Errorf("metrics lack an unlabelled gol_clients_connected:\n%s", rec.Body)
+	}
+}
+
+func TestMetricsClientsByGame(t *testing.T) {
+	s := New(DefaultConfig())
+	for i := range maxGameSeries + 2 {
+		s.games[fmt.Sprintf("g%03d", i)] = newGame(10, 10, 5, "white", "black", int64(time.Second))
+	}
+	s.clients[&Client{gameID: "g000"}] = true
+	s.clients[&Client{gameID: "g000"}] = true
+	s.clients[&Client{gameID: "g001"}] = true
+	s.clients[&Client{gameID: "gone"}] = true // a game that does not exist
+
+	rec := httptest.NewRecorder()
+	s.metricsHandler(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		"\ngol_clients_connected 4\n",
+		"\ngol_clients_connected{game=\"g000\"} 2\n",
+		"\ngol_clients_connected{game=\"g001\"} 1\n",
+		"\ngol_clients_connected{game=\"other\"} 0\n",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics lack %q", strings.TrimSpace(want))
+		}
+	}
+	if n := strings.Count(rec.Body.String(), "gol_clients_connected{"); n != maxGameSeries+1 {
+		t.Errorf("%d labelled series, want %d", n, maxGameSeries+1)
+	}
+	if strings.Contains(rec.Body.String(), `game="gone"`) {
+		t.Error("a game that does not exist has a series")
+	}
+}
+
+func TestTerritoryMessagesRejectedInClassicGame(t *testing.T) {
+	c := dial(t, newTestServer(t))
+	c.init("g1", 20, 10)
+	for _, msgType := range []string{protocol.TypeStartRound, protocol.TypeReady} {
+		c.send(map[string]interface{}{"type": msgType, "gameID": "g1"})
+		var reply struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(c.next(protocol.TypeError), &reply)
+		if want := msgType + " is only allowed in territory games"; reply.Message != want {
+			t.Errorf("error = %q, want %q", reply.Message, want)
+		}
+	}
+}
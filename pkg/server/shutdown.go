@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// ListenAndServe serves Handler on cfg.Addr, over TLS if a certificate is
+// configured, and runs the game loop until ctx is done, then shuts down
+// gracefully. Saved games are restored once the listener is up, so /readyz
+// can report the restore. It returns an error if the server fails or the
+// shutdown takes longer than cfg.ShutdownTimeout.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{Addr: s.cfg.Addr, Handler: s.Handler()}
+
+	loop, stopLoop := context.WithCancel(context.Background())
+	loopDone := make(chan struct{})
+	go func() {
+		s.gameLoop(loop)
+		close(loopDone)
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.cfg.TLSCert != "" {
+			serveErr <- srv.ListenAndServeTLS(s.cfg.TLSCert, s.cfg.TLSKey)
+		} else {
+			serveErr <- srv.ListenAndServe()
+		}
+	}()
+
+	if s.cfg.StateFile != "" {
+		s.setReadiness(stateRestoring)
+		if err := s.loadGames(s.cfg.StateFile); err != nil {
+			stopLoop()
+			srv.Close()
+			return fmt.Errorf("restoring games: %w", err)
+		}
+	}
+	s.setReadiness(stateReady)
+
+	select {
+	case err := <-serveErr:
+		stopLoop()
+		return err
+	case <-ctx.Done():
+	}
+	s.setReadiness(stateShuttingDown)
+	slog.Info("shutting down", "deadline", s.cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- s.shutdown(shutdownCtx, srv, stopLoop, loopDone) }()
+	select {
+	case err := <-done:
+		return err
+	case <-shutdownCtx.Done():
+		return fmt.Errorf("shutdown did not finish within %v", s.cfg.ShutdownTimeout)
+	}
+}
+
+// shutdown stops accepting connections, stops the game loop and round
+// timers, tells every client the server is going away and saves the games
+// if a state file is configured.
+func (s *Server) shutdown(ctx context.Context, srv *http.Server, stopLoop context.CancelFunc, loopDone <-chan struct{}) error {
+	var errs []error
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http shutdown: %w", err))
+	}
+
+	stopLoop()
+	select {
+	case <-loopDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.stopRoundTimers()
+
+	s.mu.Lock()
+	connected := make([]*Client, 0, len(s.clients))
+	for client := range s.clients {
+		connected = append(connected, client)
+	}
+	s.mu.Unlock()
+	for _, client := range connected {
+		s.closeClient(client, websocket.CloseGoingAway, "server shutting down")
+	}
+	slog.Info("client connections closed", "count", len(connected))
+
+	if s.cfg.StateFile != "" {
+		if err := s.saveGames(s.cfg.StateFile); err != nil {
+			errs = append(errs, fmt.Errorf("saving games: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopRoundTimers cancels the setup timers of territory rounds so none fires
+// during shutdown.
+func (s *Server) stopRoundTimers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, game := range s.games {
+		game.mu.Lock()
+		if game.Round != nil {
+			game.Round.stopTimer()
+		}
+		game.mu.Unlock()
+	}
+}
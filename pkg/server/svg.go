@@ -0,0 +1,141 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"GameOfLife/pkg/engine"
+)
+
+// gridColor is the stroke used for the optional grid lines.
+const gridColor = "#333"
+
+// maxSVGCells caps the cells an SVG export may cover, as the document grows
+// with every live cell. Larger boards must be exported in crops.
+const maxSVGCells = 1 << 20
+
+// cropRect is the rectangle of board cells an SVG export covers.
+type cropRect struct {
+	X, Y, W, H int
+}
+
+// parseCrop reads a "crop=x,y,w,h" parameter in board coordinates and clips
+// it to the playable area. An empty value selects the whole playable area.
+func parseCrop(s string, width, height int) (cropRect, error) {
+	full := cropRect{X: 1, Y: 1, W: width - 2, H: height - 2}
+	if full.W <= 0 || full.H <= 0 {
+		return cropRect{}, fmt.Errorf("board has no playable area")
+	}
+	if s == "" {
+		return full, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return cropRect{}, fmt.Errorf("crop must be x,y,w,h")
+	}
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return cropRect{}, fmt.Errorf("crop must be x,y,w,h")
+		}
+		v[i] = n
+	}
+	x0, y0 := max(v[0], full.X), max(v[1], full.Y)
+	x1, y1 := min(v[0]+v[2], full.X+full.W), min(v[1]+v[3], full.Y+full.H)
+	if v[2] <= 0 || v[3] <= 0 || x1 <= x0 || y1 <= y0 {
+		return cropRect{}, fmt.Errorf("crop does not overlap the board")
+	}
+	return cropRect{X: x0, Y: y0, W: x1 - x0, H: y1 - y0}, nil
+}
+
+// renderSVG draws the cells inside rect as an SVG document. Horizontal runs
+// of live cells are merged into a single rect to keep the output compact.
+func (g *Game) renderSVG(rect cropRect, grid bool) []byte {
+	cellSize := max(g.CellSize, 1)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		rect.W*cellSize, rect.H*cellSize, rect.W, rect.H)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`+"\n", rect.W, rect.H, html.EscapeString(g.BackgroundColor))
+	// One group per fill colour: the cell colour plus any team colours.
+	fills := append([]string{g.Color}, teamColors[g.Mode]...)
+	groups := make([]bytes.Buffer, len(fills))
+	for y := 0; y < rect.H; y++ {
+		row := g.Board[rect.Y+y]
+		for x := 0; x < rect.W; x++ {
+			if row[rect.X+x] < engine.Alive {
+				continue
+			}
+			owner := g.Owner(rect.X+x, rect.Y+y)
+			if owner >= len(groups) {
+				owner = 0 // an owner the mode has no colour for is drawn neutral
+			}
+			run := 1
+			for x+run < rect.W && row[rect.X+x+run] >= engine.Alive && g.Owner(rect.X+x+run, rect.Y+y) == owner {
+				run++
+			}
+			fmt.Fprintf(&groups[owner], `<rect x="%d" y="%d" width="%d" height="1"/>`+"\n", x, y, run)
+			x += run - 1
+		}
+	}
+	for i := range groups {
+		group := &groups[i]
+		if i > 0 && group.Len() == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, `<g fill="%s">`+"\n", html.EscapeString(fills[i]))
+		buf.Write(group.Bytes())
+		buf.WriteString("</g>\n")
+	}
+	if grid {
+		fmt.Fprintf(&buf, `<path stroke="%s" stroke-width="0.05" d="`, gridColor)
+		for x := 0; x <= rect.W; x++ {
+			fmt.Fprintf(&buf, "M%d 0v%d", x, rect.H)
+		}
+		for y := 0; y <= rect.H; y++ {
+			fmt.Fprintf(&buf, "M0 %dh%d", y, rect.W)
+		}
+		buf.WriteString(`"/>` + "\n")
+	}
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// snapshotSVGHandler serves the current generation of a game as an SVG.
+// Optional query parameters: grid=true to draw cell borders and
+// crop=x,y,w,h to export only part of the board.
+func (s *Server) snapshotSVGHandler(w http.ResponseWriter, r *http.Request) {
+	game, gameID, ok := s.lookupGame(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	grid := false
+	if v := query.Get("grid"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "grid must be true or false", http.StatusBadRequest)
+			return
+		}
+		grid = b
+	}
+	snap := game.snapshot()
+	rect, err := parseCrop(query.Get("crop"), snap.Width, snap.Height)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rect.W*rect.H > maxSVGCells {
+		http.Error(w, fmt.Sprintf("crop of %dx%d cells is larger than the %d cells an SVG may hold", rect.W, rect.H, maxSVGCells), http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(snap.renderSVG(rect, grid))
+	slog.Info("SVG snapshot served", "gameID", gameID)
+}
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"GameOfLife/pkg/protocol"
+)
+
+// modeTerritory is a two player game played in rounds: each player places a
+// budget of cells in their half of the board, then the simulation runs for a
+// fixed number of generations and the larger population wins.
+const modeTerritory = "territory"
+
+// Round phases. A round goes waiting -> setup -> running -> finished, and the
+// owner can start the next one from finished.
+const (
+	phaseWaiting  = "waiting"
+	phaseSetup    = "setup"
+	phaseRunning  = "running"
+	phaseFinished = "finished"
+)
+
+// Territory defaults and limits for the "budget", "generations" and
+// "setupSeconds" fields of an "init" message.
+const (
+	defaultBudget       = 50
+	maxBudget           = 1000
+	defaultGenerations  = 200
+	maxGenerations      = 5000
+	defaultSetupSeconds = 30
+	maxSetupSeconds     = 600
+)
+
+// Round is the state machine of a territory game. It is guarded by the
+// game's mu.
+type Round struct {
+	Phase       string
+	Number      int
+	Generation  int
+	Generations int
+	Budget      int
+	Remaining   [2]int // cells each team may still place this round
+	Ready       [2]bool
+	Setup       time.Duration
+	Deadline    time.Time // end of the setup phase
+	Winner      int       // winning team of the last finished round, 0 for a draw
+	Scores      [2]int    // final populations of the last finished round
+
+	timer *time.Timer // ends the setup phase
+}
+
+// stopTimer cancels the setup timer, if one is running. The caller must
+// hold the game's mu.
+func (r *Round) stopTimer() {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+}
+
+// parseRound reads the territory settings of an "init" message.
+func parseRound(msg map[string]interface{}) (*Round, error) {
+	r := &Round{Phase: phaseWaiting, Budget: defaultBudget, Generations: defaultGenerations, Setup: defaultSetupSeconds * time.Second}
+	fields := []struct {
+		name string
+		max  int
+		set  func(int)
+	}{
+		{"budget", maxBudget, func(n int) { r.Budget = n }},
+		{"generations", maxGenerations, func(n int) { r.Generations = n }},
+		{"setupSeconds", maxSetupSeconds, func(n int) { r.Setup = time.Duration(n) * time.Second }},
+	}
+	for _, f := range fields {
+		v, ok := msg[f.name]
+		if !ok {
+			continue
+		}
+		n, ok := v.(float64)
+		if !ok || n < 1 || n > float64(f.max) {
+			return nil, fmt.Errorf("%s must be a number between 1 and %d", f.name, f.max)
+		}
+		f.set(int(n))
+	}
+	return r, nil
+}
+
+// inHalf reports whether column x lies in the half of the board that
+// belongs to team: the left half for team 1 and the right half for team 2.
+func (g *Game) inHalf(team, x int) bool {
+	if team == 1 {
+		return x < g.Width/2
+	}
+	return x >= g.Width/2
+}
+
+// placeTerritoryCell validates and applies a birth during the setup phase.
+func (g *Game) placeTerritoryCell(team, x, y int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.Round
+	switch {
+	case r.Phase != phaseSetup:
+		return fmt.Errorf("cells can only be placed during the setup phase")
+	case team == 0:
+		return fmt.Errorf("only the two players can place cells")
+	case !g.inHalf(team, x):
+		return fmt.Errorf("cells must be placed in your own half of the board")
+	case r.Remaining[team-1] == 0:
+		return fmt.Errorf("no cells left in your budget")
+	}
+	if !g.Birth(x, y) {
+		return fmt.Errorf("cell (%d, %d) is outside the board or already alive", x, y)
+	}
+	g.Owners[y][x] = uint8(team)
+	r.Remaining[team-1]--
+	return nil
+}
+
+// startRound clears the board and opens the setup phase of the next round.
+// The setup phase ends when both players are ready or its timer fires.
+func (s *Server) startRound(game *Game, gameID string) error {
+	s.mu.Lock()
+	var teams [2]bool
+	for client := range s.clients {
+		if client.gameID == gameID && client.team > 0 {
+			teams[client.team-1] = true
+		}
+	}
+	s.mu.Unlock()
+	if !teams[0] || !teams[1] {
+		return fmt.Errorf("a round needs two players")
+	}
+
+	game.mu.Lock()
+	r := game.Round
+	if r.Phase != phaseWaiting && r.Phase != phaseFinished {
+		game.mu.Unlock()
+		return fmt.Errorf("round %d is still in progress", r.Number)
+	}
+	game.Clear()
+	game.Stopped = true
+	r.Phase = phaseSetup
+	r.Number++
+	r.Generation = 0
+	r.Remaining = [2]int{r.Budget, r.Budget}
+	r.Ready = [2]bool{}
+	r.Deadline = time.Now().Add(r.Setup)
+	number := r.Number
+	r.timer = time.AfterFunc(r.Setup, func() { s.beginRunning(game, gameID, number) })
+	game.mu.Unlock()
+
+	slog.Info("round setup started", "gameID", gameID, "round", number)
+	s.broadcastRound(game, gameID)
+	s.broadcastGameState(game, gameID)
+	return nil
+}
+
+// markReady records that a player has finished placing cells and starts the
+// simulation once both have.
+func (s *Server) markReady(game *Game, gameID string, team int) error {
+	game.mu.Lock()
+	r := game.Round
+	if r.Phase != phaseSetup || team == 0 {
+		game.mu.Unlock()
+		return fmt.Errorf("only players can get ready during the setup phase")
+	}
+	r.Ready[team-1] = true
+	allReady := r.Ready[0] && r.Ready[1]
+	number := r.Number
+	game.mu.Unlock()
+
+	if allReady {
+		s.beginRunning(game, gameID, number)
+	} else {
+		s.broadcastRound(game, gameID)
+	}
+	return nil
+}
+
+// beginRunning ends the setup phase of round number, unless that round has
+// already moved on or the game has been removed.
+func (s *Server) beginRunning(game *Game, gameID string, number int) {
+	// Holding s.mu keeps removeGame from running between the check and the
+	// phase change.
+	s.mu.Lock()
+	game.mu.Lock()
+	r := game.Round
+	if s.games[gameID] != game || r.Phase != phaseSetup || r.Number != number {
+		game.mu.Unlock()
+		s.mu.Unlock()
+		return
+	}
+	r.Phase = phaseRunning
+	game.Stopped = false
+	game.mu.Unlock()
+	s.mu.Unlock()
+
+	slog.Info("round running", "gameID", gameID, "round", number)
+	s.broadcastRound(game, gameID)
+	s.broadcastGameState(game, gameID)
+}
+
+// advanceRound counts a generation of a running round and finishes the
+// round once the generation limit is reached or every cell has died.
+func (s *Server) advanceRound(game *Game, gameID string) {
+	game.mu.Lock()
+	r := game.Round
+	if r.Phase != phaseRunning {
+		game.mu.Unlock()
+		return
+	}
+	r.Generation++
+	if r.Generation < r.Generations && !game.Stopped {
+		game.mu.Unlock()
+		return
+	}
+	populations := game.CountTeams()
+	r.Phase = phaseFinished
+	r.Scores = [2]int{populations[0], populations[1]}
+	switch {
+	case populations[0] > populations[1]:
+		r.Winner = 1
+	case populations[1] > populations[0]:
+		r.Winner = 2
+	default:
+		r.Winner = 0
+	}
+	game.Stopped = true
+	number, winner, scores := r.Number, r.Winner, r.Scores
+	game.mu.Unlock()
+
+	slog.Info("round finished", "gameID", gameID, "round", number, "winner", winner, "scores", scores)
+	s.broadcastResult(game, gameID, number, winner, scores)
+	s.broadcastRound(game, gameID)
+}
+
+// roundMessage describes the round for clients. The caller must hold g.mu.
+func (g *Game) roundMessage(gameID string) map[string]interface{} {
+	r := g.Round
+	secondsLeft := 0
+	if r.Phase == phaseSetup {
+		secondsLeft = max(int(time.Until(r.Deadline).Seconds()+0.5), 0)
+	}
+	return map[string]interface{}{
+		"type":        protocol.TypeRound,
+		"gameID":      gameID,
+		"phase":       r.Phase,
+		"round":       r.Number,
+		"generation":  r.Generation,
+		"generations": r.Generations,
+		"budget":      r.Budget,
+		"remaining":   r.Remaining,
+		"ready":       r.Ready,
+		"secondsLeft": secondsLeft,
+	}
+}
+
+// broadcastRound sends the round state to everyone in the game.
+func (s *Server) broadcastRound(game *Game, gameID string) {
+	game.mu.Lock()
+	msg := game.roundMessage(gameID)
+	game.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcastMessage(gameID, msg)
+}
+
+// broadcastResult announces the outcome of a round, naming the winning
+// player if there is one.
+func (s *Server) broadcastResult(game *Game, gameID string, number, winner int, scores [2]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg := map[string]interface{}{
+		"type":   protocol.TypeResult,
+		"gameID": gameID,
+		"round":  number,
+		"winner": winner,
+		"scores": scores,
+	}
+	for client := range s.clients {
+		if client.gameID == gameID && winner > 0 && client.team == winner {
+			msg["winnerID"] = client.id
+			msg["winnerName"] = client.name
+		}
+	}
+	s.broadcastMessage(gameID, msg)
+}
+
+// handleTerritoryMessage applies the territory rules to a message from a
+// player. It returns handled = true when the message has been dealt with,
+// with err describing why it was refused.
+func (s *Server) handleTerritoryMessage(client *Client, game *Game, gameID string, msg map[string]interface{}) (handled bool, err error) {
+	switch msg["type"] {
+	case protocol.TypeBirth:
+		x, okX := msg["x"].(float64)
+		y, okY := msg["y"].(float64)
+		if !okX || !okY {
+			return true, fmt.Errorf("birth needs numeric x and y")
+		}
+		if err := game.placeTerritoryCell(client.team, int(x), int(y)); err != nil {
+			return true, err
+		}
+		s.broadcastGameState(game, gameID)
+		s.broadcastRound(game, gameID)
+		return true, nil
+	case protocol.TypeStartRound:
+		if client.role != roleOwner {
+			return true, fmt.Errorf("only the owner can start a round")
+		}
+		return true, s.startRound(game, gameID)
+	case protocol.TypeReady:
+		return true, s.markReady(game, gameID, client.team)
+	case protocol.TypePattern, protocol.TypeRandomBirth, protocol.TypeClear, protocol.TypeStop, protocol.TypeResume, protocol.TypeResize:
+		return true, fmt.Errorf("%v is not allowed in territory games", msg["type"])
+	}
+	return false, nil
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"fmt"
+
+	"GameOfLife/pkg/engine"
+	"GameOfLife/pkg/protocol"
+)
+
+// maxZoom is the largest block side a client may ask the server to
+// summarise into one density byte.
+const maxZoom = 64
+
+// parseViewport reads the rectangle and zoom of a "viewport" message. Zoom
+// defaults to 1. A zero width or height cancels the subscription, after
+// which the client receives the whole board again.
+func parseViewport(msg map[string]interface{}) (protocol.Viewport, error) {
+	var v protocol.Viewport
+	fields := []struct {
+		name string
+		v    *int
+	}{{"x", &v.X}, {"y", &v.Y}, {"width", &v.Width}, {"height", &v.Height}}
+	for _, f := range fields {
+		n, ok := msg[f.name].(float64)
+		if !ok {
+			return protocol.Viewport{}, fmt.Errorf("viewport needs a numeric %s", f.name)
+		}
+		*f.v = int(n)
+	}
+	if v.Width <= 0 || v.Height <= 0 {
+		return protocol.Viewport{}, nil
+	}
+	v.Zoom = 1
+	if zoom, ok := msg["zoom"]; ok {
+		n, ok := zoom.(float64)
+		if !ok || n < 1 || n > maxZoom {
+			return protocol.Viewport{}, fmt.Errorf("zoom must be between 1 and %d", maxZoom)
+		}
+		v.Zoom = int(n)
+	}
+	return v, nil
+}
+
+// clip returns the part of v that lies on a width x height board. Zoomed
+// out viewports are first widened to whole blocks on a grid anchored at
+// (0, 0), so the blocks stay put while the viewport pans.
+func clip(v protocol.Viewport, width, height int) protocol.Viewport {
+	x0, y0 := v.X, v.Y
+	x1, y1 := v.X+v.Width, v.Y+v.Height
+	if v.Zoom > 1 {
+		x0, y0 = floorTo(x0, v.Zoom), floorTo(y0, v.Zoom)
+		x1, y1 = -floorTo(-x1, v.Zoom), -floorTo(-y1, v.Zoom)
+	}
+	x0, y0 = min(max(x0, 0), width), min(max(y0, 0), height)
+	x1, y1 = min(x1, width), min(y1, height)
+	return protocol.Viewport{X: x0, Y: y0, Width: max(x1-x0, 0), Height: max(y1-y0, 0), Zoom: v.Zoom}
+}
+
+// floorTo rounds n down to a multiple of m.
+func floorTo(n, m int) int {
+	if n < 0 {
+		return -((-n + m - 1) / m * m)
+	}
+	return n / m * m
+}
+
+// boardStates returns the state of the game as seen through each of the
+// given viewports, all taken at the same generation.
+func (g *Game) boardStates(viewports map[protocol.Viewport]bool) map[protocol.Viewport]protocol.BoardState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	state := protocol.BoardState{
+		Width:           g.Width,
+		Height:          g.Height,
+		CellSize:        g.CellSize,
+		Color:           g.Color,
+		BackgroundColor: g.BackgroundColor,
+		Interval:        g.Interval,
+		Stopped:         g.Stopped,
+		Mode:            g.Mode,
+		Rule:            g.Rule.String(),
+		TeamColors:      teamColors[g.Mode],
+	}
+	states := make(map[protocol.Viewport]protocol.BoardState, len(viewports))
+	for v := range viewports {
+		states[v] = g.viewState(state, v)
+	}
+	return states
+}
+
+// viewState fills in the cells of state that lie inside the client's
+// viewport v, or the whole board if v is zero. The caller must hold g.mu.
+func (g *Game) viewState(state protocol.BoardState, v protocol.Viewport) protocol.BoardState {
+	if v.Zoom == 0 {
+		state.Board = protocol.EncodeRows(g.Board)
+		state.Owners = protocol.EncodeRows(g.Owners)
+		return state
+	}
+	view := clip(v, g.Width, g.Height)
+	state.View = &view
+	if view.Zoom == 1 {
+		state.Board = protocol.EncodeRows(crop(g.Board, view))
+		state.Owners = protocol.EncodeRows(crop(g.Owners, view))
+		return state
+	}
+	density, owners := g.downsample(view)
+	state.Density = protocol.EncodeRows(density)
+	state.Owners = protocol.EncodeRows(owners)
+	return state
+}
+
+// crop returns the rows of cells inside view, or nil if cells is nil.
+func crop(cells [][]uint8, view protocol.Viewport) [][]uint8 {
+	if cells == nil {
+		return nil
+	}
+	rows := make([][]uint8, view.Height)
+	for i := range rows {
+		rows[i] = cells[view.Y+i][view.X : view.X+view.Width]
+	}
+	return rows
+}
+
+// downsample summarises each Zoom x Zoom block of view by its share of live
+// cells, scaled to 0..255, and, in team games, by the team owning most of
+// them. Blocks cut short by the edge of the board count the missing cells
+// as dead. The caller must hold g.mu.
+func (g *Game) downsample(view protocol.Viewport) (density, owners [][]uint8) {
+	zoom := view.Zoom
+	cols := (view.Width + zoom - 1) / zoom
+	rows := (view.Height + zoom - 1) / zoom
+	density = make([][]uint8, rows)
+	if g.Owners != nil {
+		owners = make([][]uint8, rows)
+	}
+	counts := make([]int, g.Teams+1)
+	for by := range density {
+		density[by] = make([]uint8, cols)
+		if owners != nil {
+			owners[by] = make([]uint8, cols)
+		}
+		for bx := range density[by] {
+			live := 0
+			clear(counts)
+			for y := view.Y + by*zoom; y < min(view.Y+(by+1)*zoom, view.Y+view.Height); y++ {
+				for x := view.X + bx*zoom; x < min(view.X+(bx+1)*zoom, view.X+view.Width); x++ {
+					if g.Board[y][x] < engine.Alive {
+						continue
+					}
+					live++
+					if owners != nil && int(g.Owners[y][x]) <= g.Teams {
+						counts[g.Owners[y][x]]++
+					}
+				}
+			}
+			density[by][bx] = uint8(live * 255 / (zoom * zoom))
+			if owners != nil {
+				most := 0
+				for team := 1; team <= g.Teams; team++ {
+					if counts[team] > most {
+						owners[by][bx], most = uint8(team), counts[team]
+					}
+				}
+			}
+		}
+	}
+	return density, owners
+}